@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"time"
 )
@@ -198,24 +198,34 @@ func MakeAuxiliaryGraphForMagnet(graph [][]trio, maglevel int) [][]duo {
 	return auxGraph
 }
 
+// FindWeightInAuxGraph возвращает наименьший вес среди параллельных дуг index -> pathInd:
+// именно по такой дуге проходит путь, найденный алгоритмом Дейкстры
 func FindWeightInAuxGraph(auxGraph [][]duo, index int, pathInd int) int {
 	n := len(auxGraph[index])
+	weight, found := 0, false
 	for i := 0; i < n; i++ {
-		if auxGraph[index][i].EndPoint == pathInd {
-			return auxGraph[index][i].Weight
+		if auxGraph[index][i].EndPoint == pathInd && (!found || auxGraph[index][i].Weight < weight) {
+			weight, found = auxGraph[index][i].Weight, true
 		}
 	}
-	return 0
+	return weight
 }
 
-func FindEdgeTypeInGraph(graph [][]trio, index int, pathInd int) EdgeType {
-	n := len(graph[index])
-	for i := 0; i < n; i++ {
-		if graph[index][i].EndPoint == pathInd {
-			return graph[index][i].EdgeType
+// FindEdgeInGraph находит дугу index -> pathInd наименьшего веса, переводящую путь с уровня level
+// на уровень nextLevel; при параллельных дугах тип нельзя восстановить по одной паре вершин
+func FindEdgeInGraph(graph [][]trio, c Constraint, index int, level int, pathInd int, nextLevel int) (trio, bool) {
+	var best trio
+	found := false
+	magnetVertex := ContainsMagnetEdges(graph[index])
+	for _, e := range graph[index] {
+		if e.EndPoint != pathInd || found && e.Weight >= best.Weight {
+			continue
+		}
+		if next, ok := NextLevel(c, e.EdgeType, level, magnetVertex); ok && next == nextLevel {
+			best, found = e, true
 		}
 	}
-	return 0
+	return best, found
 }
 
 func MakeSourcePathForMix(path []int, auxGraph [][]duo, lenGraph int) []fourths {
@@ -237,12 +247,18 @@ func MakeSourcePathForMix(path []int, auxGraph [][]duo, lenGraph int) []fourths
 	return truePath
 }
 
-func MakeSourcePathForBarrier(path []int, graph [][]trio, auxGraph [][]duo, lenGraph int) []fourths {
+// MakeSourcePathForBarrier восстанавливает дуги исходного графа по пути на вспомогательном графе
+// для ограничения c (барьерного или магнитного); номер вершины пути равен level*len(graph)+v
+func MakeSourcePathForBarrier(path []int, graph [][]trio, c Constraint) []fourths {
+	lenGraph := len(graph)
 	truePath := make([]fourths, 0, len(path)-1)
 	for i := 0; i < len(path)-1; i++ {
-		truePath = append(truePath,
-			fourths{path[i] % lenGraph, path[i+1] % lenGraph, FindWeightInAuxGraph(auxGraph, path[i], path[i+1]),
-				FindEdgeTypeInGraph(graph, path[i]%lenGraph, path[i+1]%lenGraph)})
+		from, to := path[i]%lenGraph, path[i+1]%lenGraph
+		e, ok := FindEdgeInGraph(graph, c, from, path[i]/lenGraph, to, path[i+1]/lenGraph)
+		if !ok {
+			panic(fmt.Sprintf("%v", path))
+		}
+		truePath = append(truePath, fourths{from, to, e.Weight, e.EdgeType})
 	}
 	return truePath
 }
@@ -251,9 +267,10 @@ func DeijkstraAlgorithm(graph [][]duo, startPoint int, finishPoint int) ([]int,
 	n := len(graph)
 	dists, labels, prevPoints := make([]int, n), make([]bool, n), make([]int, n)
 	path := make([]int, 0, n)
-	for i := 1; i < n; i++ {
+	for i := 0; i < n; i++ {
 		dists[i] = int(^uint(0) >> 1)
 	}
+	dists[startPoint] = 0
 	for i := 0; i < n; i++ {
 		v := -1
 
@@ -266,6 +283,9 @@ func DeijkstraAlgorithm(graph [][]duo, startPoint int, finishPoint int) ([]int,
 			break
 		}
 		labels[v] = true
+		if v == finishPoint {
+			break
+		}
 
 		for j := 0; j < len(graph[v]); j++ {
 			to, length := graph[v][j].EndPoint, graph[v][j].Weight
@@ -275,6 +295,9 @@ func DeijkstraAlgorithm(graph [][]duo, startPoint int, finishPoint int) ([]int,
 			}
 		}
 	}
+	if dists[finishPoint] == int(^uint(0)>>1) {
+		return []int{startPoint}, dists[finishPoint]
+	}
 	for v := finishPoint; v != startPoint; v = prevPoints[v] {
 		path = append([]int{v}, path...)
 	}
@@ -283,12 +306,39 @@ func DeijkstraAlgorithm(graph [][]duo, startPoint int, finishPoint int) ([]int,
 }
 
 func DeijkstraAlgorithmForAuxGraph(graph [][]duo, startPoint int, finishPoint int, limitlevel int, lenSourceGraph int) ([]int, int) {
+	path, dist, _ := deijkstraAlgorithmForAuxGraph(graph, startPoint, finishPoint, limitlevel, lenSourceGraph, LevelSpec{}, false)
+	return path, dist
+}
+
+// DeijkstraAlgorithmForAuxGraphFromLevel начинает путь в вершине startPoint на уровне startLevel,
+// путь возвращается в номерах вершин вспомогательного графа
+func DeijkstraAlgorithmForAuxGraphFromLevel(graph [][]duo, startPoint int, startLevel int, finishPoint int, limitlevel int, lenSourceGraph int) ([]int, int) {
+	return DeijkstraAlgorithmForAuxGraphWithLevels(graph, startPoint, finishPoint, limitlevel, lenSourceGraph, LevelSpec{startLevel, nil})
+}
+
+// DeijkstraAlgorithmForAuxGraphWithLevels ищет путь из (startPoint, spec.StartLevel) до финиша
+// на одном из уровней, допускаемых spec
+func DeijkstraAlgorithmForAuxGraphWithLevels(graph [][]duo, startPoint int, finishPoint int, limitlevel int, lenSourceGraph int, spec LevelSpec) ([]int, int) {
+	path, dist, _ := deijkstraAlgorithmForAuxGraph(graph, startPoint, finishPoint, limitlevel, lenSourceGraph, spec, false)
+	return path, dist
+}
+
+// DeijkstraAlgorithmForAuxGraphWithPotential дополнительно возвращает расстояния
+// до вершин вспомогательного графа в виде таблицы [вершина исходного графа][уровень]
+func DeijkstraAlgorithmForAuxGraphWithPotential(graph [][]duo, startPoint int, finishPoint int, limitlevel int, lenSourceGraph int) ([]int, int, [][]int) {
+	return deijkstraAlgorithmForAuxGraph(graph, startPoint, finishPoint, limitlevel, lenSourceGraph, LevelSpec{}, true)
+}
+
+func deijkstraAlgorithmForAuxGraph(graph [][]duo, startPoint int, finishPoint int, limitlevel int, lenSourceGraph int, spec LevelSpec, settleAll bool) ([]int, int, [][]int) {
 	n := len(graph)
 	dists, labels, prevPoints := make([]int, n), make([]bool, n), make([]int, n)
 	path := make([]int, 0, n)
-	for i := 1; i < n; i++ {
+	for i := 0; i < n; i++ {
 		dists[i] = int(^uint(0) >> 1)
 	}
+	startPoint += spec.StartLevel * lenSourceGraph
+	dists[startPoint] = 0
+	finishDist := int(^uint(0) >> 1)
 	for i := 0; i < n; i++ {
 		v := -1
 
@@ -297,10 +347,13 @@ func DeijkstraAlgorithmForAuxGraph(graph [][]duo, startPoint int, finishPoint in
 				v = j
 			}
 		}
-		if dists[v] == int(^uint(0)>>1) {
+		if dists[v] == int(^uint(0)>>1) || !settleAll && dists[v] > finishDist {
 			break
 		}
 		labels[v] = true
+		if v%lenSourceGraph == finishPoint && v/lenSourceGraph <= limitlevel && spec.Accepts(v/lenSourceGraph) && finishDist == int(^uint(0)>>1) {
+			finishDist = dists[v]
+		}
 
 		for j := 0; j < len(graph[v]); j++ {
 			to, length := graph[v][j].EndPoint, graph[v][j].Weight
@@ -312,449 +365,289 @@ func DeijkstraAlgorithmForAuxGraph(graph [][]duo, startPoint int, finishPoint in
 	}
 
 	minFinishPoint := finishPoint
-	minDist := dists[minFinishPoint]
-	for i := 1; i <= limitlevel; i++ {
-		if dists[finishPoint+i*lenSourceGraph] < minDist {
+	minDist := int(^uint(0) >> 1)
+	for i := 0; i <= limitlevel; i++ {
+		if spec.Accepts(i) && dists[finishPoint+i*lenSourceGraph] < minDist {
 			minFinishPoint = finishPoint + i*lenSourceGraph
 			minDist = dists[finishPoint+i*lenSourceGraph]
 		}
 	}
 
-	for v := minFinishPoint; v != startPoint; v = prevPoints[v] {
-		path = append([]int{v}, path...)
+	if minDist != int(^uint(0)>>1) {
+		for v := minFinishPoint; v != startPoint; v = prevPoints[v] {
+			path = append([]int{v}, path...)
+		}
 	}
 	path = append([]int{startPoint}, path...)
-	return path, dists[minFinishPoint]
+	potential := make([][]int, lenSourceGraph)
+	for v := range potential {
+		potential[v] = make([]int, limitlevel+1)
+		for l := range potential[v] {
+			potential[v][l] = dists[v+l*lenSourceGraph]
+		}
+	}
+	return path, minDist, potential
 }
 
 func DeijkstraVectorAlgorithmForBarrier(graph [][]trio, startPoint int, finishPoint int, barlevel int) ([]int, int) {
 	defer func(t time.Time) {
 		fmt.Println("Время работы векторного алгоритма Дейкстры", time.Since(t))
 	}(time.Now())
-	n := len(graph)
-	dists, labels, prevPoints := make([][]int, n), make([][]bool, n), make([][]duoPath, n)
-	currLevel := 0
-	path := make([]int, 0, n)
-	for i := range dists {
-		dists[i] = make([]int, barlevel+1)
-		labels[i] = make([]bool, barlevel+1)
-		prevPoints[i] = make([]duoPath, barlevel+1)
-	}
-
-	for j := 1; j <= barlevel; j++ {
-		dists[0][j] = int(^uint(0) >> 1)
-	}
+	path, dist, _ := deijkstraVectorAlgorithmForBarrier(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, barlevel, false)
+	return path, dist
+}
 
-	for i := 1; i < n; i++ {
-		for j := 0; j <= barlevel; j++ {
-			dists[i][j] = int(^uint(0) >> 1)
-		}
-	}
+func DeijkstraVectorAlgorithmForBarrierWithPotential(graph [][]trio, startPoint int, finishPoint int, barlevel int) ([]int, int, [][]int) {
+	return deijkstraVectorAlgorithmForBarrier(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, barlevel, true)
+}
 
-	for z := 0; z <= (barlevel+1)*n; z++ {
-		v := -1
-		firstPoint := true
-		for q := 0; q <= barlevel; q++ {
-			for j := 0; j < n; j++ {
-				if firstPoint {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][q]) {
-						v = j
-						currLevel = q
-						firstPoint = false
-					}
-				} else {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][currLevel]) {
-						v = j
-						currLevel = q
-					}
-				}
-			}
+// deijkstraVectorAlgorithmForBarrier при settleAll=false останавливается, как только финиш извлечен
+// и остальные уровни финиша уже не могут дать меньшее расстояние
+func deijkstraVectorAlgorithmForBarrier(graph *Graph, sources []Source, finishPoints []int, acceptLevel func(int) bool, barlevel int, settleAll bool) ([]int, int, [][]int) {
+	n := graph.N()
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(n, barlevel+1, sources, finishPoints)
+
+	finishDist := int(^uint(0) >> 1)
+	for {
+		v, currLevel, ok := w.next()
+		if !ok {
+			break
 		}
-
-		if dists[v][currLevel] == int(^uint(0)>>1) {
+		dist := w.dist(v, currLevel)
+		if !settleAll && dist > finishDist {
 			break
 		}
+		if w.isFinish(v) && (acceptLevel == nil || acceptLevel(currLevel)) && finishDist == int(^uint(0)>>1) {
+			finishDist = dist
+		}
 
-		labels[v][currLevel] = true
-
-		for _, g := range graph[v] {
-			to, length, edgeType := g.EndPoint, g.Weight, g.EdgeType
-			switch edgeType {
+		for e := graph.Offsets[v]; e < graph.Offsets[v+1]; e++ {
+			to, length := int(graph.Targets[e]), graph.Weights[e]
+			switch EdgeType(graph.Types[e]) {
 			case Normal:
-				if dists[v][currLevel]+length < dists[to][currLevel] {
-					dists[to][currLevel] = dists[v][currLevel] + length
-					prevPoints[to][currLevel].PrevPoint = v
-					prevPoints[to][currLevel].EdgeType = 0
-				}
+				w.relax(to, currLevel, dist+length, v, 0)
 			case Boosting:
 				if currLevel < barlevel {
-					if dists[v][currLevel]+length < dists[to][currLevel+1] {
-						dists[to][currLevel+1] = dists[v][currLevel] + length
-						prevPoints[to][currLevel+1].PrevPoint = v
-						prevPoints[to][currLevel+1].EdgeType = 2
-					}
+					w.relax(to, currLevel+1, dist+length, v, 2)
 				} else {
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
+					w.relax(to, currLevel, dist+length, v, 0)
 				}
 			case Barrier:
 				if currLevel >= barlevel {
-					if dists[v][currLevel]+length < dists[to][0] {
-						dists[to][0] = dists[v][currLevel] + length
-						prevPoints[to][0].PrevPoint = v
-						prevPoints[to][0].EdgeType = 3
-					}
+					w.relax(to, 0, dist+length, v, 3)
 				}
 			}
 		}
 	}
 
-	minDistLevel := 0
-	minDist := dists[finishPoint][0]
-	for i := 1; i <= barlevel; i++ {
-		if dists[finishPoint][i] < minDist {
-			minDist = dists[finishPoint][i]
-			minDistLevel = i
-		}
+	var potential [][]int
+	if settleAll {
+		potential = w.potential()
 	}
-	k := minDistLevel
-	v := finishPoint
-	for v != startPoint || k != 0 {
-		path = append([]int{v}, path...)
-		switch prevPoints[v][k].EdgeType {
-		case 0:
-			v = prevPoints[v][k].PrevPoint
+	v, k := w.bestFinish(finishPoints, acceptLevel)
+	if v == -1 {
+		return nil, int(^uint(0) >> 1), potential
+	}
+	dist := w.dist(v, k)
+	path := make([]int, 0)
+	for {
+		path = append(path, v)
+		prev := w.prevPoint(v, k)
+		if prev.PrevPoint == -1 {
+			break
+		}
+		v = prev.PrevPoint
+		switch prev.EdgeType {
 		case 2:
-			v = prevPoints[v][k].PrevPoint
 			k--
-
 		case 3:
-			v = prevPoints[v][k].PrevPoint
 			k = barlevel
 		}
 	}
-	path = append([]int{startPoint}, path...)
-
-	return path, dists[finishPoint][minDistLevel]
+	reverseInts(path)
+	return path, dist, potential
 }
 
 func DeijkstraVectorAlgorithmForMagnet(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int) {
 	defer func(t time.Time) {
 		fmt.Println("Время работы векторного алгоритма:", time.Since(t))
 	}(time.Now())
-	n := len(graph)
-	dists, labels, prevPoints := make([][]int, n), make([][]bool, n), make([][]duoPath, n)
-	currLevel := 0
-	path := make([]int, 0, n)
-	for i := range dists {
-		dists[i] = make([]int, maglevel+1)
-		labels[i] = make([]bool, maglevel+1)
-		prevPoints[i] = make([]duoPath, maglevel+1)
-	}
-	for j := 1; j <= maglevel; j++ {
-		dists[0][j] = int(^uint(0) >> 1)
-	}
-	for i := 1; i < n; i++ {
-		for j := 0; j <= maglevel; j++ {
-			dists[i][j] = int(^uint(0) >> 1)
-		}
-	}
+	path, dist, _ := deijkstraVectorAlgorithmForMagnet(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, maglevel, false)
+	return path, dist
+}
 
-	for z := 0; z <= (maglevel+1)*n; z++ {
-		v := -1
-		firstPoint := true
-		for q := 0; q <= maglevel; q++ {
-			for j := 0; j < n; j++ {
-				if firstPoint {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][q]) {
-						v = j
-						currLevel = q
-						firstPoint = false
-					}
-				} else {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][currLevel]) {
-						v = j
-						currLevel = q
-					}
-				}
-			}
-		}
+func DeijkstraVectorAlgorithmForMagnetWithPotential(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int, [][]int) {
+	return deijkstraVectorAlgorithmForMagnet(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, maglevel, true)
+}
 
-		if dists[v][currLevel] == int(^uint(0)>>1) {
+func deijkstraVectorAlgorithmForMagnet(graph *Graph, sources []Source, finishPoints []int, acceptLevel func(int) bool, maglevel int, settleAll bool) ([]int, int, [][]int) {
+	n := graph.N()
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(n, maglevel+1, sources, finishPoints)
+
+	finishDist := int(^uint(0) >> 1)
+	for {
+		v, currLevel, ok := w.next()
+		if !ok {
 			break
 		}
+		dist := w.dist(v, currLevel)
+		if !settleAll && dist > finishDist {
+			break
+		}
+		if w.isFinish(v) && (acceptLevel == nil || acceptLevel(currLevel)) && finishDist == int(^uint(0)>>1) {
+			finishDist = dist
+		}
 
-		labels[v][currLevel] = true
-
-		if currLevel == maglevel {
-			var containsMagnetEdges = false
-			for _, g := range graph[v] {
-				to, length, edgeType := g.EndPoint, g.Weight, g.EdgeType
-				if edgeType == 4 {
-					containsMagnetEdges = true
-					if dists[v][currLevel]+length < dists[to][currLevel-1] {
-						dists[to][currLevel-1] = dists[v][currLevel] + length
-						prevPoints[to][currLevel-1].PrevPoint = v
-						prevPoints[to][currLevel-1].EdgeType = 4
-					}
-				}
-			}
-			if !containsMagnetEdges {
-				for _, g := range graph[v] {
-					to, length := g.EndPoint, g.Weight
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
+		for e := graph.Offsets[v]; e < graph.Offsets[v+1]; e++ {
+			to, length := int(graph.Targets[e]), graph.Weights[e]
+			edgeType := EdgeType(graph.Types[e])
+			if currLevel == maglevel {
+				if !graph.MagnetVertex(v) {
+					w.relax(to, currLevel, dist+length, v, 0)
+				} else if edgeType == Magnet && currLevel > 0 {
+					w.relax(to, currLevel-1, dist+length, v, 4)
 				}
+				continue
 			}
-		} else {
-			for _, g := range graph[v] {
-				to, length, edgeType := g.EndPoint, g.Weight, g.EdgeType
-				switch edgeType {
-				case Normal:
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
-				case Boosting:
-					if dists[v][currLevel]+length < dists[to][currLevel+1] {
-						dists[to][currLevel+1] = dists[v][currLevel] + length
-						prevPoints[to][currLevel+1].PrevPoint = v
-						prevPoints[to][currLevel+1].EdgeType = 2
-					}
-				case Magnet:
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
-				}
+			switch edgeType {
+			case Normal, Magnet:
+				w.relax(to, currLevel, dist+length, v, 0)
+			case Boosting:
+				w.relax(to, currLevel+1, dist+length, v, 2)
 			}
 		}
 	}
-	minDistLevel := 0
-	minDist := dists[finishPoint][0]
-	for i := 1; i <= maglevel; i++ {
-		if dists[finishPoint][i] < minDist {
-			minDist = dists[finishPoint][i]
-			minDistLevel = i
-		}
+
+	var potential [][]int
+	if settleAll {
+		potential = w.potential()
 	}
-	k := minDistLevel
-	v := finishPoint
-	for v != startPoint || k != 0 {
-		path = append([]int{v}, path...)
-		switch prevPoints[v][k].EdgeType {
-		case 0:
-			v = prevPoints[v][k].PrevPoint
+	v, k := w.bestFinish(finishPoints, acceptLevel)
+	if v == -1 {
+		return nil, int(^uint(0) >> 1), potential
+	}
+	dist := w.dist(v, k)
+	path := make([]int, 0)
+	for {
+		path = append(path, v)
+		prev := w.prevPoint(v, k)
+		if prev.PrevPoint == -1 {
+			break
+		}
+		v = prev.PrevPoint
+		switch prev.EdgeType {
 		case 2:
-			v = prevPoints[v][k].PrevPoint
 			k--
 		case 4:
-			v = prevPoints[v][k].PrevPoint
 			k++
 		}
 	}
-	path = append([]int{startPoint}, path...)
-	return path, dists[finishPoint][minDistLevel]
+	reverseInts(path)
+	return path, dist, potential
 }
 
 func DeijkstraVectorAlgorithmForMagnetBarrier(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int) {
 	defer func(t time.Time) {
 		fmt.Println("Время работы векторного алгоритма", time.Since(t))
 	}(time.Now())
-	n := len(graph)
-	dists, labels, prevPoints := make([][]int, n), make([][]bool, n), make([][]duoPath, n)
-	currLevel := 0
-	path := make([]int, 0, n)
-	for i := range dists {
-		dists[i] = make([]int, maglevel+1)
-		labels[i] = make([]bool, maglevel+1)
-		prevPoints[i] = make([]duoPath, maglevel+1)
-	}
+	path, dist, _ := deijkstraVectorAlgorithmForMagnetBarrier(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, maglevel, false)
+	return path, dist
+}
 
-	for j := 1; j <= maglevel; j++ {
-		dists[0][j] = int(^uint(0) >> 1)
-	}
+func DeijkstraVectorAlgorithmForMagnetBarrierWithPotential(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int, [][]int) {
+	return deijkstraVectorAlgorithmForMagnetBarrier(NewGraph(graph), []Source{{startPoint, 0, 0}}, []int{finishPoint}, nil, maglevel, true)
+}
 
-	for i := 1; i < n; i++ {
-		for j := 0; j <= maglevel; j++ {
-			dists[i][j] = int(^uint(0) >> 1)
-		}
-	}
+func deijkstraVectorAlgorithmForMagnetBarrier(graph *Graph, sources []Source, finishPoints []int, acceptLevel func(int) bool, maglevel int, settleAll bool) ([]int, int, [][]int) {
+	n := graph.N()
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(n, maglevel+1, sources, finishPoints)
 
-	for z := 0; z <= (maglevel+1)*n; z++ {
-		v := -1
-		firstPoint := true
-		for q := 0; q <= maglevel; q++ {
-			for j := 0; j < n; j++ {
-				if firstPoint {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][q]) {
-						v = j
-						currLevel = q
-						firstPoint = false
-					}
-				} else {
-					if !labels[j][q] && (v == -1 || dists[j][q] < dists[v][currLevel]) {
-						v = j
-						currLevel = q
-					}
-				}
-			}
+	finishDist := int(^uint(0) >> 1)
+	for {
+		v, currLevel, ok := w.next()
+		if !ok {
+			break
 		}
-
-		if dists[v][currLevel] == int(^uint(0)>>1) {
+		dist := w.dist(v, currLevel)
+		if !settleAll && dist > finishDist {
 			break
 		}
+		if w.isFinish(v) && (acceptLevel == nil || acceptLevel(currLevel)) && finishDist == int(^uint(0)>>1) {
+			finishDist = dist
+		}
 
-		labels[v][currLevel] = true
-
-		if currLevel == maglevel {
-			var containsMagnetEdges = false
-			for _, g := range graph[v] {
-				to, length, edgeType := g.EndPoint, g.Weight, g.EdgeType
-				if edgeType == 4 {
-					containsMagnetEdges = true
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 4
-					}
+		for e := graph.Offsets[v]; e < graph.Offsets[v+1]; e++ {
+			to, length := int(graph.Targets[e]), graph.Weights[e]
+			edgeType := EdgeType(graph.Types[e])
+			if currLevel == maglevel {
+				if !graph.MagnetVertex(v) {
+					w.relax(to, currLevel, dist+length, v, 0)
+				} else if edgeType == Magnet {
+					w.relax(to, currLevel, dist+length, v, 4)
 				}
+				continue
 			}
-			if !containsMagnetEdges {
-				for _, g := range graph[v] {
-					to, length := g.EndPoint, g.Weight
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
-				}
-			}
-		} else {
-			for _, g := range graph[v] {
-				to, length, edgeType := g.EndPoint, g.Weight, g.EdgeType
-				switch edgeType {
-				case Normal:
-					if dists[v][currLevel]+length < dists[to][currLevel] {
-						dists[to][currLevel] = dists[v][currLevel] + length
-						prevPoints[to][currLevel].PrevPoint = v
-						prevPoints[to][currLevel].EdgeType = 0
-					}
-				case Boosting:
-					if dists[v][currLevel]+length < dists[to][currLevel+1] {
-						dists[to][currLevel+1] = dists[v][currLevel] + length
-						prevPoints[to][currLevel+1].PrevPoint = v
-						prevPoints[to][currLevel+1].EdgeType = 2
-					}
-				}
+			switch edgeType {
+			case Normal:
+				w.relax(to, currLevel, dist+length, v, 0)
+			case Boosting:
+				w.relax(to, currLevel+1, dist+length, v, 2)
 			}
 		}
 	}
 
-	minDistLevel := 0
-	minDist := dists[finishPoint][0]
-	for i := 1; i <= maglevel; i++ {
-		if dists[finishPoint][i] < minDist {
-			minDist = dists[finishPoint][i]
-			minDistLevel = i
-		}
+	var potential [][]int
+	if settleAll {
+		potential = w.potential()
 	}
-	k := minDistLevel
-	v := finishPoint
-	for v != startPoint || k != 0 {
-		path = append([]int{v}, path...)
-		switch prevPoints[v][k].EdgeType {
-		case 0:
-			v = prevPoints[v][k].PrevPoint
+	v, k := w.bestFinish(finishPoints, acceptLevel)
+	if v == -1 {
+		return nil, int(^uint(0) >> 1), potential
+	}
+	dist := w.dist(v, k)
+	path := make([]int, 0)
+	for {
+		path = append(path, v)
+		prev := w.prevPoint(v, k)
+		if prev.PrevPoint == -1 {
+			break
+		}
+		v = prev.PrevPoint
+		switch prev.EdgeType {
 		case 2:
-			v = prevPoints[v][k].PrevPoint
 			k--
-		case 4:
-			v = prevPoints[v][k].PrevPoint
 		}
 	}
-	path = append([]int{startPoint}, path...)
-	return path, dists[finishPoint][minDistLevel]
+	reverseInts(path)
+	return path, dist, potential
+}
+
+// ReadEdges читает m дуг после заголовка; дуги с несуществующими вершинами,
+// отрицательным весом или неизвестным типом пропускаются, чтение прекращается на первой ошибке формата
+func ReadEdges(f io.Reader, n int, m int, printEdges bool) [][]trio {
+	graph, _ := NewGraphParser(f).ReadEdges(n, m, printEdges)
+	return graph
 }
 
 func ReadGraphForMix(filename string) [][]trio {
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
-	n, m, k := 0, 0, 0
-	fmt.Fscanln(f, &n, &m, &k)
-	fmt.Println("число вершин в графе -", n)
-	fmt.Println("число дуг графа -", m)
-	fmt.Println("число запрещенных дуг -", k)
-	graph := make([][]trio, n)
-	fmt.Println("Список дуг:")
-	for i := 0; i < m; i++ {
-		edge := trio{}
-		var vertex int
-		fmt.Fscanln(f, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType)
-		fmt.Println(edge)
-		graph[vertex] = append(graph[vertex], edge)
-	}
+	graph, _ := readGraphFileOrExit(filename, ReaderOptions{MixLimit, true})
 	return graph
 }
 
 func ReadGraphForBarrier(filename string) ([][]trio, int) {
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
-	n, m, barlevel := 0, 0, 0
-	fmt.Fscanln(f, &n, &m, &barlevel)
-	fmt.Println("число вершин в графе -", n)
-	fmt.Println("число дуг графа -", m)
-	fmt.Println("уровень барьера -", barlevel)
-	graph := make([][]trio, n)
-	fmt.Println("Список дуг:")
-	for i := 0; i < m; i++ {
-		edge := trio{}
-		var vertex int
-		fmt.Fscanln(f, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType)
-		fmt.Println(edge)
-		graph[vertex] = append(graph[vertex], edge)
-	}
-	return graph, barlevel
+	graph, header := readGraphFileOrExit(filename, ReaderOptions{BarrierLimit, true})
+	return graph, header.Level
 }
 
 func ReadGraphForMagnet(filename string) ([][]trio, int) {
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
-	n, m, maglevel := 0, 0, 0
-	fmt.Fscanln(f, &n, &m, &maglevel)
-	fmt.Println("число вершин в графе -", n)
-	fmt.Println("число дуг графа -", m)
-	fmt.Println("уровень магнитности -", maglevel)
-	graph := make([][]trio, n)
-	fmt.Println("Список дуг:")
-	for i := 0; i < m; i++ {
-		edge := trio{}
-		var vertex int
-		fmt.Fscanln(f, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType)
-		fmt.Println(edge)
-		graph[vertex] = append(graph[vertex], edge)
-	}
-	return graph, maglevel
+	graph, header := readGraphFileOrExit(filename, ReaderOptions{MagnetLimit, true})
+	return graph, header.Level
 }
 
 func MixProgramm() {
@@ -916,7 +809,7 @@ func BarrierProgramm() {
 	} else {
 		fmt.Println("Путь на вспомогательном графе:", pathSourceBarrier, ", ")
 		fmt.Println("его длина:", distSourceBarrier)
-		var truePathBarrier = MakeSourcePathForBarrier(pathSourceBarrier, graphWithBarrier, auxBarrierGraph, len(graphWithBarrier))
+		var truePathBarrier = MakeSourcePathForBarrier(pathSourceBarrier, graphWithBarrier, Constraint{BarrierLimit, barlevel})
 		fmt.Println("Путь на исходном графе:")
 		fmt.Println(truePathBarrier)
 	}
@@ -1010,7 +903,7 @@ func MagnetProgramm() {
 	} else {
 		fmt.Println("Путь на вспомогательном графе:", pathSourceBarrier, ", его длина:", distSourceBarrier)
 
-		var truePathMagnet = MakeSourcePathForBarrier(pathSourceBarrier, graphWithMagnet, auxMagnetGraph, len(graphWithMagnet))
+		var truePathMagnet = MakeSourcePathForBarrier(pathSourceBarrier, graphWithMagnet, Constraint{MagnetLimit, maglevel})
 		fmt.Println("Путь на исходном графе:")
 		fmt.Println(truePathMagnet)
 	}
@@ -1098,28 +991,15 @@ func MagnetBarrierProgramm() {
 	} else {
 		fmt.Println("Путь на вспомогательном графе:", pathSourceBarrier, ", его длина:", distSourceBarrier)
 
-		var truePathMagnet = MakeSourcePathForBarrier(pathSourceBarrier, graphWithMagnetBarrier, auxMagnetBarrierGraph, len(graphWithMagnetBarrier))
+		var truePathMagnet = MakeSourcePathForBarrier(pathSourceBarrier, graphWithMagnetBarrier, Constraint{MagnetBarrierLimit, maglevel})
 		fmt.Println("Путь на исходном графе:")
 		fmt.Println(truePathMagnet)
 	}
 }
 
 func ReadGraphForBarrierSpeedTest(filename string) ([][]trio, int) {
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-	n, m, barlevel := 0, 0, 0
-	fmt.Fscanln(f, &n, &m, &barlevel)
-	graph := make([][]trio, n)
-	for i := 0; i < m; i++ {
-		edge := trio{}
-		var vertex int
-		fmt.Fscanln(f, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType)
-		graph[vertex] = append(graph[vertex], edge)
-	}
-	return graph, barlevel
+	graph, header := readGraphFileOrExit(filename, ReaderOptions{BarrierLimit, false})
+	return graph, header.Level
 }
 
 func MakeAuxBarrierAndDeijkstra(graph [][]trio, barlevel int, startPointDeijkstra int, finishPointDeijkstra int) {
@@ -1139,22 +1019,8 @@ func BarrierSpeedTestProgramm() {
 }
 
 func ReadGraphForMagnetSpeedTest(filename string) ([][]trio, int) {
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
-	n, m, maglevel := 0, 0, 0
-	fmt.Fscanln(f, &n, &m, &maglevel)
-	graph := make([][]trio, n)
-	for i := 0; i < m; i++ {
-		edge := trio{}
-		var vertex int
-		fmt.Fscanln(f, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType)
-		graph[vertex] = append(graph[vertex], edge)
-	}
-	return graph, maglevel
+	graph, header := readGraphFileOrExit(filename, ReaderOptions{MagnetLimit, false})
+	return graph, header.Level
 }
 
 func MakeAuxMagnetAndDeijkstra(graph [][]trio, maglevel int, startPointDeijkstra int, finishPointDeijkstra int) {
@@ -1213,6 +1079,20 @@ func main() {
 		MagnetSpeedTestProgramm()
 	case "magbarSpeedTest":
 		MagnetBarrierSpeedTestProgramm()
+	case "gen":
+		GenerateProgramm()
+	case "allpairs":
+		AllPairsProgramm()
+	case "snapshot":
+		SnapshotProgramm()
+	case "route":
+		RouteProgramm()
+	case "graphml":
+		GraphMLProgramm()
+	case "csv":
+		CSVProgramm()
+	case "serve":
+		ServeProgramm()
 	default:
 		panic(fmt.Sprintf("%v", typeOfLimit))
 	}
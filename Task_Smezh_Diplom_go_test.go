@@ -0,0 +1,350 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const inf = int(^uint(0) >> 1)
+
+func hasMagnetEdges(edges []trio) bool {
+	for _, e := range edges {
+		if e.EdgeType == Magnet {
+			return true
+		}
+	}
+	return false
+}
+
+// oracleStep независимо от решателей воспроизводит правила перехода между уровнями. Он намеренно
+// не вызывает NextLevel: тесты сверяют с ним решатели, а TestNextLevelMatchesOracle - сам NextLevel
+func oracleStep(graph [][]trio, limit LimitType, level int, v int, l int, e trio) (int, bool) {
+	switch limit {
+	case MixLimit:
+		switch e.EdgeType {
+		case Normal:
+			return 0, true
+		case Closed:
+			return 1, l == 0
+		}
+	case BarrierLimit:
+		switch e.EdgeType {
+		case Normal:
+			return l, true
+		case Boosting:
+			if l < level {
+				return l + 1, true
+			}
+			return l, true
+		case Barrier:
+			return 0, l == level
+		}
+	case MagnetLimit:
+		if l == level {
+			if hasMagnetEdges(graph[v]) {
+				return l - 1, e.EdgeType == Magnet && l > 0
+			}
+			return l, true
+		}
+		switch e.EdgeType {
+		case Normal, Magnet:
+			return l, true
+		case Boosting:
+			return l + 1, true
+		}
+	case MagnetBarrierLimit:
+		if l == level {
+			if hasMagnetEdges(graph[v]) {
+				return l, e.EdgeType == Magnet
+			}
+			return l, true
+		}
+		switch e.EdgeType {
+		case Normal:
+			return l, true
+		case Boosting:
+			return l + 1, true
+		}
+	}
+	return 0, false
+}
+
+func oracleLevels(limit LimitType, level int) int {
+	if limit == MixLimit {
+		return 2
+	}
+	return level + 1
+}
+
+// oracleDistance перебирает все пути без повторения состояний (вершина, уровень)
+func oracleDistance(graph [][]trio, limit LimitType, level int, start int, finish int) int {
+	return oracleDistanceFromLevel(graph, limit, level, start, 0, finish)
+}
+
+func oracleDistanceFromLevel(graph [][]trio, limit LimitType, level int, start int, startLevel int, finish int) int {
+	return oracleDistanceWithLevels(graph, limit, level, start, startLevel, finish, nil)
+}
+
+// oracleDistanceWithLevels засчитывает приход в финиш только на уровнях, допускаемых accept
+func oracleDistanceWithLevels(graph [][]trio, limit LimitType, level int, start int, startLevel int, finish int, accept func(int) bool) int {
+	levels := oracleLevels(limit, level)
+	visited := make([][]bool, len(graph))
+	for i := range visited {
+		visited[i] = make([]bool, levels)
+	}
+	best := inf
+	var dfs func(v int, l int, dist int)
+	dfs = func(v int, l int, dist int) {
+		if dist >= best {
+			return
+		}
+		if v == finish && (accept == nil || accept(l)) {
+			best = dist
+			return
+		}
+		visited[v][l] = true
+		for _, e := range graph[v] {
+			if next, ok := oracleStep(graph, limit, level, v, l, e); ok && !visited[e.EndPoint][next] {
+				dfs(e.EndPoint, next, dist+e.Weight)
+			}
+		}
+		visited[v][l] = false
+	}
+	dfs(start, startLevel, 0)
+	return best
+}
+
+func randomTestGraph(rnd *rand.Rand, limit LimitType) ([][]trio, int) {
+	cfg := DefaultGeneratorConfig(limit)
+	cfg.N = 2 + rnd.Intn(6)
+	cfg.M = rnd.Intn(3 * cfg.N)
+	cfg.Level = rnd.Intn(4)
+	if limit == MagnetLimit && cfg.Level == 0 {
+		cfg.Level = 1
+	}
+	cfg.MaxWeight = 1 + rnd.Intn(20)
+	cfg.Seed = rnd.Int63()
+	cfg.Multigraph = rnd.Intn(2) == 0
+	cfg.Proportions = DefaultProportions(limit)
+	for t := range cfg.Proportions {
+		if cfg.Proportions[t] > 0 {
+			cfg.Proportions[t] = rnd.Float64()
+		}
+	}
+	graph, err := GenerateGraph(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return graph, cfg.Level
+}
+
+func auxDistance(graph [][]trio, limit LimitType, level int, start int, finish int) int {
+	var auxGraph [][]duo
+	switch limit {
+	case MixLimit:
+		auxGraph, level = MakeAuxiliaryGraphForMix(graph), 1
+	case BarrierLimit:
+		auxGraph = MakeAuxiliaryGraphForBarrier(graph, level)
+	case MagnetLimit:
+		auxGraph = MakeAuxiliaryGraphForMagnet(graph, level)
+	case MagnetBarrierLimit:
+		auxGraph = MakeAuxiliaryGraphForMagnetBarrier(graph, level)
+	}
+	_, dist := DeijkstraAlgorithmForAuxGraph(auxGraph, start, finish, level, len(graph))
+	return dist
+}
+
+func vectorDistance(graph [][]trio, limit LimitType, level int, start int, finish int) int {
+	var dist int
+	switch limit {
+	case BarrierLimit:
+		_, dist = DeijkstraVectorAlgorithmForBarrier(graph, start, finish, level)
+	case MagnetLimit:
+		_, dist = DeijkstraVectorAlgorithmForMagnet(graph, start, finish, level)
+	case MagnetBarrierLimit:
+		_, dist = DeijkstraVectorAlgorithmForMagnetBarrier(graph, start, finish, level)
+	}
+	return dist
+}
+
+func TestOracleOnBarrierExample(t *testing.T) {
+	//дешевый барьер 0->2 доступен только после двух ускорений по петле 0->1->0
+	graph := [][]trio{
+		{{1, 5, Boosting}, {2, 1, Barrier}},
+		{{0, 5, Boosting}},
+		{},
+	}
+	if dist := oracleDistance(graph, BarrierLimit, 2, 0, 2); dist != 11 {
+		t.Fatalf("oracle: got %d, want 11", dist)
+	}
+	if dist := auxDistance(graph, BarrierLimit, 2, 0, 2); dist != 11 {
+		t.Fatalf("aux: got %d, want 11", dist)
+	}
+	if dist := vectorDistance(graph, BarrierLimit, 2, 0, 2); dist != 11 {
+		t.Fatalf("vector: got %d, want 11", dist)
+	}
+}
+
+func TestSolversAgreeWithOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 2000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			finish := rnd.Intn(len(graph))
+			want := oracleDistance(graph, limit, level, 0, finish)
+			if got := auxDistance(graph, limit, level, 0, finish); got != want {
+				t.Fatalf("%v level %d finish %d: aux %d, oracle %d\n%v", limit, level, finish, got, want, graph)
+			}
+			if limit == MixLimit {
+				continue
+			}
+			if got := vectorDistance(graph, limit, level, 0, finish); got != want {
+				t.Fatalf("%v level %d finish %d: vector %d, oracle %d\n%v", limit, level, finish, got, want, graph)
+			}
+		}
+	}
+}
+
+func TestEarlyTerminationKeepsResults(t *testing.T) {
+	rnd := rand.New(rand.NewSource(6))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			n := len(graph)
+			finish := rnd.Intn(n)
+			var path, fullPath []int
+			var dist, fullDist int
+			switch limit {
+			case MixLimit:
+				auxGraph := MakeAuxiliaryGraphForMix(graph)
+				path, dist = DeijkstraAlgorithmForAuxGraph(auxGraph, 0, finish, 1, n)
+				fullPath, fullDist, _ = DeijkstraAlgorithmForAuxGraphWithPotential(auxGraph, 0, finish, 1, n)
+			case BarrierLimit:
+				path, dist = DeijkstraVectorAlgorithmForBarrier(graph, 0, finish, level)
+				fullPath, fullDist, _ = DeijkstraVectorAlgorithmForBarrierWithPotential(graph, 0, finish, level)
+			case MagnetLimit:
+				path, dist = DeijkstraVectorAlgorithmForMagnet(graph, 0, finish, level)
+				fullPath, fullDist, _ = DeijkstraVectorAlgorithmForMagnetWithPotential(graph, 0, finish, level)
+			case MagnetBarrierLimit:
+				path, dist = DeijkstraVectorAlgorithmForMagnetBarrier(graph, 0, finish, level)
+				fullPath, fullDist, _ = DeijkstraVectorAlgorithmForMagnetBarrierWithPotential(graph, 0, finish, level)
+			}
+			if dist != fullDist || !equalInts(path, fullPath) {
+				t.Fatalf("%v level %d finish %d: early %v %d, full %v %d", limit, level, finish, path, dist, fullPath, fullDist)
+			}
+		}
+	}
+}
+
+func TestSolversFromAnyStart(t *testing.T) {
+	rnd := rand.New(rand.NewSource(12))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			n := len(graph)
+			c := Constraint{limit, level}
+			start, startLevel, finish := rnd.Intn(n), rnd.Intn(c.Levels()), rnd.Intn(n)
+
+			want := oracleDistanceFromLevel(graph, limit, level, start, startLevel, finish)
+			var auxGraph [][]duo
+			switch limit {
+			case MixLimit:
+				auxGraph = MakeAuxiliaryGraphForMix(graph)
+			case BarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForBarrier(graph, level)
+			case MagnetLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnet(graph, level)
+			case MagnetBarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnetBarrier(graph, level)
+			}
+			path, got := DeijkstraAlgorithmForAuxGraphFromLevel(auxGraph, start, startLevel, finish, c.Levels()-1, n)
+			if got != want || path[0] != start+startLevel*n || got != inf && path[len(path)-1]%n != finish {
+				t.Fatalf("%v level %d from (%d, %d) to %d: aux %v %d, oracle %d\n%v", limit, level, start, startLevel, finish, path, got, want, graph)
+			}
+			if limit != MixLimit {
+				path, got = DeijkstraVectorAlgorithmFromLevel(graph, c, start, startLevel, finish)
+				if got != want || path[0] != start || got != inf && path[len(path)-1] != finish {
+					t.Fatalf("%v level %d from (%d, %d) to %d: vector %v %d, oracle %d\n%v", limit, level, start, startLevel, finish, path, got, want, graph)
+				}
+			}
+
+			want = oracleDistance(graph, limit, level, start, finish)
+			solvers := map[string]func() ([]int, int){
+				"astar":         func() ([]int, int) { return AStarVectorAlgorithm(graph, c, start, finish, nil) },
+				"bidirectional": func() ([]int, int) { return BidirectionalDeijkstraAlgorithm(graph, c, start, finish) },
+			}
+			if limit != MixLimit {
+				solvers["vector"] = func() ([]int, int) { return DeijkstraVectorAlgorithmFromLevel(graph, c, start, 0, finish) }
+			}
+			for name, solve := range solvers {
+				path, got := solve()
+				if got != want {
+					t.Fatalf("%v level %d from %d to %d: %s %d, oracle %d\n%v", limit, level, start, finish, name, got, want, graph)
+				}
+				if got == inf {
+					continue
+				}
+				if path[0] != start || path[len(path)-1] != finish || minRealisedWeight(graph, limit, level, path) != got {
+					t.Fatalf("%v level %d: %s path %v does not realise %d from %d\n%v", limit, level, name, path, got, start, graph)
+				}
+			}
+		}
+	}
+}
+
+func TestDeijkstraAlgorithmFromAnyStart(t *testing.T) {
+	rnd := rand.New(rand.NewSource(13))
+	for i := 0; i < 1000; i++ {
+		graph, _ := randomTestGraph(rnd, BarrierLimit)
+		n := len(graph)
+		normal := make([][]trio, n)
+		for v := range graph {
+			for _, e := range graph[v] {
+				normal[v] = append(normal[v], trio{e.EndPoint, e.Weight, Normal})
+			}
+		}
+		start, finish := rnd.Intn(n), rnd.Intn(n)
+		want := oracleDistance(normal, BarrierLimit, 0, start, finish)
+		path, got := DeijkstraAlgorithm(MakeSimpleGraph(graph), start, finish)
+		if got != want || path[0] != start || got != inf && path[len(path)-1] != finish {
+			t.Fatalf("%d to %d: got %v %d, want %d\n%v", start, finish, path, got, want, graph)
+		}
+	}
+}
+
+func TestSolversRespectLevelSpec(t *testing.T) {
+	rnd := rand.New(rand.NewSource(14))
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			n := len(graph)
+			c := Constraint{limit, level}
+			start, finish := rnd.Intn(n), rnd.Intn(n)
+			spec := LevelSpec{StartLevel: rnd.Intn(c.Levels())}
+			if rnd.Intn(2) == 0 {
+				spec.AcceptLevel = AtLeastLevel(rnd.Intn(c.Levels()))
+			} else {
+				spec.AcceptLevel = LevelSet(rnd.Intn(c.Levels()), rnd.Intn(c.Levels()))
+			}
+
+			want := oracleDistanceWithLevels(graph, limit, level, start, spec.StartLevel, finish, spec.AcceptLevel)
+			var auxGraph [][]duo
+			switch limit {
+			case BarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForBarrier(graph, level)
+			case MagnetLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnet(graph, level)
+			case MagnetBarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnetBarrier(graph, level)
+			}
+			path, got := DeijkstraAlgorithmForAuxGraphWithLevels(auxGraph, start, finish, level, n, spec)
+			if got != want || got != inf && (path[len(path)-1]%n != finish || !spec.Accepts(path[len(path)-1]/n)) {
+				t.Fatalf("%v level %d from (%d, %d) to %d: aux %v %d, oracle %d\n%v", limit, level, start, spec.StartLevel, finish, path, got, want, graph)
+			}
+			path, got = DeijkstraVectorAlgorithmWithLevels(graph, c, start, finish, spec)
+			if got != want || path[0] != start || got != inf && path[len(path)-1] != finish {
+				t.Fatalf("%v level %d from (%d, %d) to %d: vector %v %d, oracle %d\n%v", limit, level, start, spec.StartLevel, finish, path, got, want, graph)
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type VertexPair struct {
+	From, To int
+}
+
+// distancesFromSource извлекает все состояния векторным алгоритмом из (старт, 0) и для каждой вершины
+// берет лучший из уровней
+func distancesFromSource(graph *Graph, c Constraint, startPoint int) []int {
+	sources, finish := []Source{{startPoint, 0, 0}}, []int{startPoint}
+	var potential [][]int
+	switch c.Type {
+	case MixLimit:
+		potential = settleMixFromSource(graph, startPoint)
+	case BarrierLimit:
+		_, _, potential = deijkstraVectorAlgorithmForBarrier(graph, sources, finish, nil, c.Level, true)
+	case MagnetLimit:
+		_, _, potential = deijkstraVectorAlgorithmForMagnet(graph, sources, finish, nil, c.Level, true)
+	case MagnetBarrierLimit:
+		_, _, potential = deijkstraVectorAlgorithmForMagnetBarrier(graph, sources, finish, nil, c.Level, true)
+	default:
+		panic(fmt.Sprintf("%v", c.Type))
+	}
+
+	best := make([]int, len(potential))
+	for v, dists := range potential {
+		best[v] = dists[0]
+		for _, d := range dists[1:] {
+			if d < best[v] {
+				best[v] = d
+			}
+		}
+	}
+	return best
+}
+
+// settleMixFromSource - обход для mix на рабочих массивах векторного алгоритма: у mix нет векторного
+// решателя, а алгоритм на вспомогательном графе без кучи слишком медленный для всех пар
+func settleMixFromSource(graph *Graph, startPoint int) [][]int {
+	c := Constraint{MixLimit, 0}
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(graph.N(), c.Levels(), []Source{{startPoint, 0, 0}}, nil)
+	for {
+		v, level, ok := w.next()
+		if !ok {
+			break
+		}
+		for i := graph.Offsets[v]; i < graph.Offsets[v+1]; i++ {
+			e := graph.Edge(i)
+			if next, ok := NextLevel(c, e.EdgeType, level, false); ok {
+				w.relax(e.EndPoint, next, w.dist(v, level)+e.Weight, v, int(e.EdgeType))
+			}
+		}
+	}
+	return w.potential()
+}
+
+// DistancesFromSource возвращает расстояния с учетом ограничения от старта до каждой вершины
+func DistancesFromSource(graph [][]trio, c Constraint, startPoint int) []int {
+	return distancesFromSource(NewGraph(graph), c, startPoint)
+}
+
+// AllPairsDistances запускает поиск из каждой вершины на GOMAXPROCS потоках;
+// matrix[s][f] равно int(^uint(0) >> 1), если f недостижима из s
+func AllPairsDistances(graph [][]trio, c Constraint) [][]int {
+	return AllPairsDistancesOnGraph(NewGraph(graph), c)
+}
+
+func AllPairsDistancesOnGraph(graph *Graph, c Constraint) [][]int {
+	n := graph.N()
+	matrix := make([][]int, n)
+	sources := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range sources {
+				matrix[s] = distancesFromSource(graph, c, s)
+			}
+		}()
+	}
+	for s := 0; s < n; s++ {
+		sources <- s
+	}
+	close(sources)
+	wg.Wait()
+	return matrix
+}
+
+func UnreachablePairs(matrix [][]int) []VertexPair {
+	pairs := make([]VertexPair, 0)
+	for s := range matrix {
+		for f, d := range matrix[s] {
+			if d == int(^uint(0)>>1) {
+				pairs = append(pairs, VertexPair{s, f})
+			}
+		}
+	}
+	return pairs
+}
+
+// WriteDistanceMatrixCSV пишет строку заголовка с номерами вершин и по строке на каждый старт;
+// недостижимые пары записываются как "inf"
+func WriteDistanceMatrixCSV(w io.Writer, matrix [][]int) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(matrix)+1)
+	for f := range matrix {
+		record[f+1] = strconv.Itoa(f)
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for s, row := range matrix {
+		record[0] = strconv.Itoa(s)
+		for f, d := range row {
+			if d == int(^uint(0)>>1) {
+				record[f+1] = "inf"
+			} else {
+				record[f+1] = strconv.Itoa(d)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var distanceMatrixMagic = [4]byte{'C', 'D', 'M', '1'}
+
+// WriteDistanceMatrixBinary пишет сигнатуру CDM1, число вершин (uint32) и матрицу по строкам
+// в int64 little-endian; недостижимые пары записываются как -1
+func WriteDistanceMatrixBinary(w io.Writer, matrix [][]int) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(distanceMatrixMagic[:])
+	binary.Write(bw, binary.LittleEndian, uint32(len(matrix)))
+	buf := make([]byte, 8)
+	for _, row := range matrix {
+		for _, d := range row {
+			if d == int(^uint(0)>>1) {
+				d = -1
+			}
+			binary.LittleEndian.PutUint64(buf, uint64(int64(d)))
+			bw.Write(buf)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadDistanceMatrixBinary читает матрицу целиком в память и разбирает ее через DecodeDistanceMatrixBinary
+func ReadDistanceMatrixBinary(r io.Reader) ([][]int, error) {
+	r, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeDistanceMatrixBinary(data)
+}
+
+func DecodeDistanceMatrixBinary(data []byte) ([][]int, error) {
+	if len(data) < 8 {
+		return nil, errors.New("матрица расстояний слишком короткая")
+	}
+	if [4]byte{data[0], data[1], data[2], data[3]} != distanceMatrixMagic {
+		return nil, errors.New("неизвестный формат матрицы расстояний")
+	}
+	n := uint64(binary.LittleEndian.Uint32(data[4:]))
+	//размер проверяется до выделения памяти: испорченный заголовок не должен приводить к огромному make
+	rest := uint64(len(data) - 8)
+	if rest%8 != 0 || n*n != rest/8 {
+		return nil, fmt.Errorf("размер матрицы расстояний не соответствует заголовку: n=%d", n)
+	}
+
+	matrix := make([][]int, n)
+	p := data[8:]
+	for s := range matrix {
+		matrix[s] = make([]int, n)
+		for f := range matrix[s] {
+			d := int(int64(binary.LittleEndian.Uint64(p)))
+			if d == -1 {
+				d = int(^uint(0) >> 1)
+			}
+			matrix[s][f] = d
+			p = p[8:]
+		}
+	}
+	return matrix, nil
+}
+
+func AllPairsProgramm() {
+	var filename, outFilename, format string
+
+	fmt.Print("Введите имя файла с графом: ")
+	fmt.Fscan(os.Stdin, &filename)
+	g, h, err := LoadGraphFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	header := promptLimitUnlessDeclared(h)
+	fmt.Print("Введите имя файла для матрицы и формат (csv, bin): ")
+	fmt.Fscan(os.Stdin, &outFilename, &format)
+
+	start := time.Now()
+	matrix := AllPairsDistancesOnGraph(g, header.Constraint())
+	fmt.Println("Время построения матрицы расстояний:", time.Since(start))
+
+	f, err := os.Create(outFilename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	switch format {
+	case "csv":
+		err = WriteDistanceMatrixCSV(f, matrix)
+	case "bin":
+		err = WriteDistanceMatrixBinary(f, matrix)
+	default:
+		panic(fmt.Sprintf("%v", format))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	unreachable := UnreachablePairs(matrix)
+	fmt.Println("Число недостижимых пар -", len(unreachable))
+	for _, p := range unreachable {
+		fmt.Println(p.From, "->", p.To)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAllPairsMatchesOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(10))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 300; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			matrix := AllPairsDistances(graph, Constraint{limit, level})
+			for s := range graph {
+				for f := range graph {
+					if want := oracleDistance(graph, limit, level, s, f); matrix[s][f] != want {
+						t.Fatalf("%v level %d %d->%d: got %d, want %d\n%v", limit, level, s, f, matrix[s][f], want, graph)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDistanceMatrixOutput(t *testing.T) {
+	graph := [][]trio{
+		{{1, 4, Normal}},
+		{},
+	}
+	matrix := AllPairsDistances(graph, Constraint{BarrierLimit, 1})
+	if pairs := UnreachablePairs(matrix); len(pairs) != 1 || pairs[0] != (VertexPair{1, 0}) {
+		t.Fatalf("unreachable pairs: %v", pairs)
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteDistanceMatrixCSV(&csvOut, matrix); err != nil {
+		t.Fatal(err)
+	}
+	if want := ",0,1\n0,0,4\n1,inf,0\n"; csvOut.String() != want {
+		t.Fatalf("csv: got %q, want %q", csvOut.String(), want)
+	}
+
+	var binOut bytes.Buffer
+	if err := WriteDistanceMatrixBinary(&binOut, matrix); err != nil {
+		t.Fatal(err)
+	}
+	if binOut.Len() != 4+4+8*4 {
+		t.Fatalf("binary matrix takes %d bytes", binOut.Len())
+	}
+	read, err := ReadDistanceMatrixBinary(&binOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s := range matrix {
+		if !equalInts(read[s], matrix[s]) {
+			t.Fatalf("binary round trip: got %v, want %v", read, matrix)
+		}
+	}
+	if _, err := ReadDistanceMatrixBinary(strings.NewReader("XXXX")); err == nil {
+		t.Fatal("wrong signature accepted")
+	}
+	//заголовок с огромным n и обрезанная матрица отвергаются до выделения памяти
+	for _, data := range [][]byte{
+		append([]byte("CDM1"), 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0),
+		[]byte("CDM1"),
+		append(append([]byte(nil), "CDM1\x02\x00\x00\x00"...), make([]byte, 8*3)...),
+	} {
+		if _, err := DecodeDistanceMatrixBinary(data); err == nil {
+			t.Errorf("%q: corrupt matrix accepted", data)
+		}
+	}
+}
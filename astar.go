@@ -0,0 +1,141 @@
+package main
+
+import "math"
+
+type Coordinates struct {
+	X float64
+	Y float64
+}
+
+type statePoint struct {
+	Vertex int
+	Level  int
+}
+
+// EuclideanHeuristic берет координаты из графа g и допустима, если вес любой дуги не меньше weightPerUnit,
+// умноженного на длину отрезка между ее концами
+func EuclideanHeuristic(g *Graph, finishPoint int, weightPerUnit float64) func(int) int {
+	return coordinateHeuristic(g, finishPoint, func(a Coordinates, b Coordinates) float64 {
+		return math.Hypot(a.X-b.X, a.Y-b.Y) * weightPerUnit
+	})
+}
+
+const earthRadiusKm = 6371.0
+
+// HaversineHeuristic оценивает расстояние по дуге большого круга; weightPerKm - минимальный вес дуги на километр
+func HaversineHeuristic(g *Graph, finishPoint int, weightPerKm float64) func(int) int {
+	return coordinateHeuristic(g, finishPoint, func(a Coordinates, b Coordinates) float64 {
+		return HaversineDistance(a, b) * weightPerKm
+	})
+}
+
+// coordinateHeuristic дает оценку 0 для вершин с неизвестным положением (NaN) и для графа без координат:
+// такая оценка всегда допустима
+func coordinateHeuristic(g *Graph, finishPoint int, distance func(Coordinates, Coordinates) float64) func(int) int {
+	coords := g.Coordinates()
+	located := func(v int) bool {
+		return coords != nil && !math.IsNaN(coords[v].X) && !math.IsNaN(coords[v].Y)
+	}
+	if !located(finishPoint) {
+		return func(int) int { return 0 }
+	}
+	finish := coords[finishPoint]
+	return func(v int) int {
+		if !located(v) {
+			return 0
+		}
+		return int(distance(coords[v], finish))
+	}
+}
+
+func HaversineDistance(a Coordinates, b Coordinates) float64 {
+	lat1, lat2 := a.Y*math.Pi/180, b.Y*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.X-a.X)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// AStarAlgorithmForAuxGraph - вариант DeijkstraAlgorithmForAuxGraph с допустимой эвристикой heuristic(вершина исходного графа);
+// поиск завершается, как только финиш извлечен на любом уровне
+func AStarAlgorithmForAuxGraph(graph [][]duo, startPoint int, finishPoint int, limitlevel int, lenSourceGraph int, heuristic func(int) int) ([]int, int) {
+	n := len(graph)
+	dists, labels, prevPoints := make([]int, n), make([]bool, n), make([]int, n)
+	for i := range dists {
+		dists[i] = int(^uint(0) >> 1)
+		prevPoints[i] = -1
+	}
+	estimate := func(v int) int {
+		if heuristic == nil {
+			return 0
+		}
+		return heuristic(v % lenSourceGraph)
+	}
+	dists[startPoint] = 0
+	queue := indexHeap{{startPoint, estimate(startPoint)}}
+
+	minFinishPoint := -1
+	for len(queue) > 0 {
+		item := queue.pop()
+		v := item.Index
+		if labels[v] || item.Dist > dists[v]+estimate(v) {
+			continue
+		}
+		if v%lenSourceGraph == finishPoint && v/lenSourceGraph <= limitlevel {
+			minFinishPoint = v
+			break
+		}
+		labels[v] = true
+
+		for _, e := range graph[v] {
+			if dists[v]+e.Weight < dists[e.EndPoint] {
+				dists[e.EndPoint] = dists[v] + e.Weight
+				prevPoints[e.EndPoint] = v
+				//при несогласованной эвристике вершину приходится открывать заново
+				labels[e.EndPoint] = false
+				queue.push(indexItem{e.EndPoint, dists[e.EndPoint] + estimate(e.EndPoint)})
+			}
+		}
+	}
+
+	if minFinishPoint == -1 {
+		return []int{startPoint}, int(^uint(0) >> 1)
+	}
+	path := make([]int, 0)
+	for v := minFinishPoint; v != -1; v = prevPoints[v] {
+		path = append(path, v)
+	}
+	reverseInts(path)
+	return path, dists[minFinishPoint]
+}
+
+// AStarVectorAlgorithm - векторный алгоритм с эвристикой, не зависящей от уровня, поэтому
+// ее допустимость сохраняется на всех уровнях; путь возвращается последовательностью вершин
+func AStarVectorAlgorithm(graph [][]trio, c Constraint, startPoint int, finishPoint int, heuristic func(int) int) ([]int, int) {
+	return AStarVectorAlgorithmOnGraph(NewGraph(graph), c, startPoint, finishPoint, heuristic)
+}
+
+// AStarVectorAlgorithmOnGraph ведет поиск на рабочих массивах векторного алгоритма с ключом кучи dist+heuristic
+func AStarVectorAlgorithmOnGraph(graph *Graph, c Constraint, startPoint int, finishPoint int, heuristic func(int) int) ([]int, int) {
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(graph.N(), c.Levels(), nil, nil)
+	w.heuristic = heuristic
+	w.relax(startPoint, 0, 0, -1, 0)
+	path := w.shortestStatePath(graph, c, finishPoint, nil)
+	if path == nil {
+		return []int{startPoint}, int(^uint(0) >> 1)
+	}
+	return path.vertices(), path.dist
+}
+
+func AStarVectorAlgorithmForBarrier(graph [][]trio, startPoint int, finishPoint int, barlevel int, heuristic func(int) int) ([]int, int) {
+	return AStarVectorAlgorithm(graph, Constraint{BarrierLimit, barlevel}, startPoint, finishPoint, heuristic)
+}
+
+func AStarVectorAlgorithmForMagnet(graph [][]trio, startPoint int, finishPoint int, maglevel int, heuristic func(int) int) ([]int, int) {
+	return AStarVectorAlgorithm(graph, Constraint{MagnetLimit, maglevel}, startPoint, finishPoint, heuristic)
+}
+
+func AStarVectorAlgorithmForMagnetBarrier(graph [][]trio, startPoint int, finishPoint int, maglevel int, heuristic func(int) int) ([]int, int) {
+	return AStarVectorAlgorithm(graph, Constraint{MagnetBarrierLimit, maglevel}, startPoint, finishPoint, heuristic)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// geometricTestGraph назначает вершинам координаты и делает вес дуги не меньше длины отрезка
+func geometricTestGraph(rnd *rand.Rand, limit LimitType) ([][]trio, int, []Coordinates) {
+	graph, level := randomTestGraph(rnd, limit)
+	coords := make([]Coordinates, len(graph))
+	for i := range coords {
+		coords[i] = Coordinates{rnd.Float64() * 10, rnd.Float64() * 10}
+	}
+	for v := range graph {
+		for i, e := range graph[v] {
+			length := math.Hypot(coords[v].X-coords[e.EndPoint].X, coords[v].Y-coords[e.EndPoint].Y)
+			graph[v][i].Weight = int(math.Ceil(length)) + rnd.Intn(3)
+		}
+	}
+	return graph, level, coords
+}
+
+func located(t *testing.T, graph [][]trio, coords []Coordinates) *Graph {
+	g, err := NewGraph(graph).WithCoordinates(coords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// randomAdmissibleHeuristic не превосходит расстояния без ограничений, но не обязана быть согласованной
+func randomAdmissibleHeuristic(rnd *rand.Rand, graph [][]trio, finish int) func(int) int {
+	n := len(graph)
+	dists := make([][]int, n)
+	for v := range dists {
+		dists[v] = make([]int, n)
+		for u := range dists[v] {
+			if u != v {
+				dists[v][u] = inf
+			}
+		}
+		for _, e := range graph[v] {
+			if e.Weight < dists[v][e.EndPoint] {
+				dists[v][e.EndPoint] = e.Weight
+			}
+		}
+	}
+	for k := 0; k < n; k++ {
+		for v := 0; v < n; v++ {
+			for u := 0; u < n; u++ {
+				if dists[v][k] != inf && dists[k][u] != inf && dists[v][k]+dists[k][u] < dists[v][u] {
+					dists[v][u] = dists[v][k] + dists[k][u]
+				}
+			}
+		}
+	}
+	h := make([]int, n)
+	for v := range h {
+		if dists[v][finish] != inf {
+			h[v] = rnd.Intn(dists[v][finish] + 1)
+		}
+	}
+	return func(v int) int { return h[v] }
+}
+
+func TestAStarMatchesOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level, coords := geometricTestGraph(rnd, limit)
+			n := len(graph)
+			finish := rnd.Intn(n)
+			want := oracleDistance(graph, limit, level, 0, finish)
+			heuristics := []func(int) int{nil, EuclideanHeuristic(located(t, graph, coords), finish, 1), randomAdmissibleHeuristic(rnd, graph, finish)}
+			for hi, h := range heuristics {
+				var path []int
+				var dist int
+				if limit == MixLimit {
+					path, dist = AStarAlgorithmForAuxGraph(MakeAuxiliaryGraphForMix(graph), 0, finish, 1, n, h)
+					for i := range path {
+						path[i] %= n
+					}
+				} else {
+					path, dist = AStarVectorAlgorithm(graph, Constraint{limit, level}, 0, finish, h)
+				}
+				if dist != want {
+					t.Fatalf("%v level %d finish %d heuristic %d: got %d, want %d\n%v", limit, level, finish, hi, dist, want, graph)
+				}
+				if dist != inf && minRealisedWeight(graph, limit, level, path) != dist {
+					t.Fatalf("%v level %d heuristic %d: path %v does not realise %d", limit, level, hi, path, dist)
+				}
+			}
+		}
+	}
+}
+
+func TestAStarForAuxGraphMatchesVector(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	for i := 0; i < 500; i++ {
+		graph, level, coords := geometricTestGraph(rnd, BarrierLimit)
+		n := len(graph)
+		finish := rnd.Intn(n)
+		h := EuclideanHeuristic(located(t, graph, coords), finish, 1)
+		_, want := AStarVectorAlgorithmForBarrier(graph, 0, finish, level, h)
+		if _, got := AStarAlgorithmForAuxGraph(MakeAuxiliaryGraphForBarrier(graph, level), 0, finish, level, n, h); got != want {
+			t.Fatalf("level %d finish %d: aux %d, vector %d", level, finish, got, want)
+		}
+	}
+}
+
+func TestHaversineDistance(t *testing.T) {
+	//Москва - Санкт-Петербург, около 634 км
+	moscow, petersburg := Coordinates{37.6173, 55.7558}, Coordinates{30.3351, 59.9343}
+	if d := HaversineDistance(moscow, petersburg); d < 630 || d > 640 {
+		t.Fatalf("got %.1f km", d)
+	}
+}
+
+func TestHeuristicWithoutCoordinates(t *testing.T) {
+	nan := math.NaN()
+	g := located(t, [][]trio{{{1, 1, Normal}}, {{2, 1, Normal}}, nil}, []Coordinates{{37.6, 55.7}, {nan, nan}, {30.3, 59.9}})
+	h := HaversineHeuristic(g, 2, 1)
+	if h(0) < 630 || h(1) != 0 || h(2) != 0 {
+		t.Fatalf("got %d %d %d", h(0), h(1), h(2))
+	}
+	if h := EuclideanHeuristic(g, 1, 1); h(0) != 0 || h(2) != 0 {
+		t.Fatal("finish without coordinates: nonzero estimate")
+	}
+	if h := EuclideanHeuristic(NewGraph([][]trio{nil, nil}), 1, 1); h(0) != 0 {
+		t.Fatal("graph without coordinates: nonzero estimate")
+	}
+}
+
+func BenchmarkAStar(b *testing.B) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 4000)
+		b.Run(fmt.Sprintf("%v/astar", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				AStarVectorAlgorithmOnGraph(g, c, 0, g.N()-1, nil)
+			}
+		})
+		b.Run(fmt.Sprintf("%v/vector", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				DeijkstraVectorAlgorithmOnGraph(g, c, 0, g.N()-1, LevelSpec{})
+			}
+		})
+	}
+}
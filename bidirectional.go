@@ -0,0 +1,92 @@
+package main
+
+// BidirectionalDeijkstraAlgorithm ведет прямой поиск из (старт, 0) и обратный поиск
+// по обращенному многоуровневому графу из финиша на всех уровнях; поиск останавливается,
+// когда сумма минимальных расстояний двух очередей не меньше лучшего найденного пути
+func BidirectionalDeijkstraAlgorithm(graph [][]trio, c Constraint, startPoint int, finishPoint int) ([]int, int) {
+	return BidirectionalDeijkstraAlgorithmOnGraph(NewGraph(graph), c, startPoint, finishPoint)
+}
+
+func BidirectionalDeijkstraAlgorithmOnGraph(graph *Graph, c Constraint, startPoint int, finishPoint int) ([]int, int) {
+	n, levels := graph.N(), c.Levels()
+	reversed := MakeReversedGraph(graph, c)
+
+	forward, backward := vectorWorkspaces.Get().(*vectorWorkspace), vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(forward)
+	defer vectorWorkspaces.Put(backward)
+	forward.reset(n, levels, []Source{{startPoint, 0, 0}}, nil)
+	finishes := make([]Source, levels)
+	for l := range finishes {
+		finishes[l] = Source{finishPoint, l, 0}
+	}
+	backward.reset(n, levels, finishes, nil)
+
+	best, meeting := int(^uint(0)>>1), statePoint{-1, -1}
+	if startPoint == finishPoint {
+		best, meeting = 0, statePoint{startPoint, 0}
+	}
+	update := func(v int, level int) {
+		df, db := forward.dist(v, level), backward.dist(v, level)
+		if df != int(^uint(0)>>1) && db != int(^uint(0)>>1) && df+db < best {
+			best, meeting = df+db, statePoint{v, level}
+		}
+	}
+
+	var prevLevels [2]int
+	for {
+		df, okf := forward.peek()
+		db, okb := backward.peek()
+		if !okf || !okb || best != int(^uint(0)>>1) && df+db >= best {
+			break
+		}
+		if df <= db {
+			v, level, _ := forward.next()
+			magnet := graph.MagnetVertex(v)
+			for i := graph.Offsets[v]; i < graph.Offsets[v+1]; i++ {
+				next, ok := NextLevel(c, EdgeType(graph.Types[i]), level, magnet)
+				if ok {
+					to := int(graph.Targets[i])
+					forward.relaxState(to, next, df+graph.Weights[i], v, level, i)
+					update(to, next)
+				}
+			}
+		} else {
+			v, level, _ := backward.next()
+			in := reversed.Graph
+			for i := in.Offsets[v]; i < in.Offsets[v+1]; i++ {
+				from := int(in.Targets[i])
+				for _, l := range appendPrevLevels(prevLevels[:0], c, EdgeType(in.Types[i]), level, in.MagnetVertex(from)) {
+					backward.relaxState(from, l, db+in.Weights[i], v, level, i)
+					update(from, l)
+				}
+			}
+		}
+	}
+
+	if meeting.Vertex == -1 {
+		return []int{startPoint}, int(^uint(0) >> 1)
+	}
+	//прямой поиск хранит путь от старта до встречи, обратный - от встречи до финиша
+	path := forward.tracePath(meeting.Vertex, meeting.Level).vertices()
+	for v, level := meeting.Vertex, meeting.Level; ; {
+		next, nextLevel, _ := backward.prevState(v, level)
+		if next == -1 {
+			break
+		}
+		path = append(path, next)
+		v, level = next, nextLevel
+	}
+	return path, best
+}
+
+func BidirectionalDeijkstraAlgorithmForBarrier(graph [][]trio, startPoint int, finishPoint int, barlevel int) ([]int, int) {
+	return BidirectionalDeijkstraAlgorithm(graph, Constraint{BarrierLimit, barlevel}, startPoint, finishPoint)
+}
+
+func BidirectionalDeijkstraAlgorithmForMagnet(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int) {
+	return BidirectionalDeijkstraAlgorithm(graph, Constraint{MagnetLimit, maglevel}, startPoint, finishPoint)
+}
+
+func BidirectionalDeijkstraAlgorithmForMagnetBarrier(graph [][]trio, startPoint int, finishPoint int, maglevel int) ([]int, int) {
+	return BidirectionalDeijkstraAlgorithm(graph, Constraint{MagnetBarrierLimit, maglevel}, startPoint, finishPoint)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBidirectionalMatchesOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 2000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			finish := rnd.Intn(len(graph))
+			want := oracleDistance(graph, limit, level, 0, finish)
+			path, dist := BidirectionalDeijkstraAlgorithm(graph, Constraint{limit, level}, 0, finish)
+			if dist != want {
+				t.Fatalf("%v level %d finish %d: got %d, want %d\n%v", limit, level, finish, dist, want, graph)
+			}
+			if dist == inf {
+				continue
+			}
+			if path[0] != 0 || path[len(path)-1] != finish || minRealisedWeight(graph, limit, level, path) != dist {
+				t.Fatalf("%v level %d finish %d: path %v does not realise %d\n%v", limit, level, finish, path, dist, graph)
+			}
+		}
+	}
+}
+
+func TestReversedGraphIsCached(t *testing.T) {
+	adj := [][]trio{{{1, 5, Boosting}, {2, 1, Magnet}}, {{2, 3, Barrier}}, {{0, 2, Normal}}}
+	g := NewGraph(adj)
+	r := g.Reversed()
+	if r != g.Reversed() {
+		t.Fatal("reversed graph is built again")
+	}
+	labeled, err := g.WithLabels([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labeled.Reversed() != r {
+		t.Fatal("labeled copy builds its own reversed graph")
+	}
+	want := [][]trio{{{2, 2, Normal}}, {{0, 5, Boosting}}, {{0, 1, Magnet}, {1, 3, Barrier}}}
+	got := r.AdjacencyList()
+	for _, edges := range got {
+		sort.Slice(edges, func(i, j int) bool { return edges[i].EndPoint < edges[j].EndPoint })
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for v := range adj {
+		if r.MagnetVertex(v) != g.MagnetVertex(v) {
+			t.Errorf("vertex %d: magnet flag differs", v)
+		}
+	}
+}
+
+func BenchmarkBidirectional(b *testing.B) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 4000)
+		b.Run(fmt.Sprintf("%v/bidirectional", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BidirectionalDeijkstraAlgorithmOnGraph(g, c, 0, g.N()-1)
+			}
+		})
+		b.Run(fmt.Sprintf("%v/vector", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				DeijkstraVectorAlgorithmOnGraph(g, c, 0, g.N()-1, LevelSpec{})
+			}
+		})
+	}
+}
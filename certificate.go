@@ -0,0 +1,88 @@
+package main
+
+import "fmt"
+
+// VerifyCertificate проверяет оптимальность пути по потенциалу potential[вершина][уровень],
+// не полагаясь на решатель: ни одна дуга многоуровневого графа не нарушает неравенство
+// треугольника, расстояние равно минимуму потенциала финиша по уровням, а каждая дуга пути
+// тугая. Проверка занимает O(E·уровней); path - последовательность вершин исходного графа
+func VerifyCertificate(graph [][]trio, c Constraint, startPoint int, finishPoint int, potential [][]int, path []int, dist int) error {
+	n, levels := len(graph), c.Levels()
+	if len(potential) != n {
+		return fmt.Errorf("потенциал задан для %d вершин, в графе %d", len(potential), n)
+	}
+	for v := range potential {
+		if len(potential[v]) != levels {
+			return fmt.Errorf("потенциал вершины %d задан для %d уровней, нужно %d", v, len(potential[v]), levels)
+		}
+	}
+	if startPoint < 0 || startPoint >= n || finishPoint < 0 || finishPoint >= n {
+		return fmt.Errorf("вершины %d и %d должны быть в графе", startPoint, finishPoint)
+	}
+	if potential[startPoint][0] != 0 {
+		return fmt.Errorf("потенциал старта равен %d", potential[startPoint][0])
+	}
+
+	for v, edges := range graph {
+		magnetVertex := ContainsMagnetEdges(edges)
+		for l, p := range potential[v] {
+			if p == int(^uint(0)>>1) {
+				continue
+			}
+			for _, e := range edges {
+				next, ok := NextLevel(c, e.EdgeType, l, magnetVertex)
+				if ok && potential[e.EndPoint][next] > p+e.Weight {
+					return fmt.Errorf("дуга %d -> %v на уровне %d нарушает неравенство треугольника", v, e, l)
+				}
+			}
+		}
+	}
+
+	best := int(^uint(0) >> 1)
+	for _, p := range potential[finishPoint] {
+		if p < best {
+			best = p
+		}
+	}
+	if best != dist {
+		return fmt.Errorf("расстояние %d, минимум потенциала финиша %d", dist, best)
+	}
+	if dist == int(^uint(0)>>1) {
+		return nil
+	}
+
+	if len(path) == 0 || path[0] != startPoint || path[len(path)-1] != finishPoint {
+		return fmt.Errorf("путь %v не ведет из %d в %d", path, startPoint, finishPoint)
+	}
+	//уровни, в которые можно прийти по тугим дугам
+	reached := make([]bool, levels)
+	reached[0] = true
+	for i := 0; i+1 < len(path); i++ {
+		v, to := path[i], path[i+1]
+		if v < 0 || v >= n || to < 0 || to >= n {
+			return fmt.Errorf("вершина шага %d пути вне графа", i)
+		}
+		magnetVertex := ContainsMagnetEdges(graph[v])
+		next := make([]bool, levels)
+		for l, ok := range reached {
+			if !ok {
+				continue
+			}
+			for _, e := range graph[v] {
+				if e.EndPoint != to {
+					continue
+				}
+				if nl, ok := NextLevel(c, e.EdgeType, l, magnetVertex); ok && potential[to][nl] == potential[v][l]+e.Weight {
+					next[nl] = true
+				}
+			}
+		}
+		reached = next
+	}
+	for l, ok := range reached {
+		if ok && potential[finishPoint][l] == dist {
+			return nil
+		}
+	}
+	return fmt.Errorf("путь %v не проходится по тугим дугам", path)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func solveWithPotential(graph [][]trio, c Constraint, finish int) ([]int, int, [][]int) {
+	n := len(graph)
+	switch c.Type {
+	case BarrierLimit:
+		return DeijkstraVectorAlgorithmForBarrierWithPotential(graph, 0, finish, c.Level)
+	case MagnetLimit:
+		return DeijkstraVectorAlgorithmForMagnetWithPotential(graph, 0, finish, c.Level)
+	case MagnetBarrierLimit:
+		return DeijkstraVectorAlgorithmForMagnetBarrierWithPotential(graph, 0, finish, c.Level)
+	}
+	path, dist, potential := DeijkstraAlgorithmForAuxGraphWithPotential(MakeAuxiliaryGraphForMix(graph), 0, finish, 1, n)
+	for i := range path {
+		path[i] %= n
+	}
+	return path, dist, potential
+}
+
+func TestSolverCertificatesVerify(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			c := Constraint{limit, level}
+			finish := rnd.Intn(len(graph))
+			path, dist, potential := solveWithPotential(graph, c, finish)
+			if err := VerifyCertificate(graph, c, 0, finish, potential, path, dist); err != nil {
+				t.Fatalf("%v level %d finish %d: %v\n%v", limit, level, finish, err, graph)
+			}
+		}
+	}
+}
+
+func TestVerifyCertificateRejectsForgeries(t *testing.T) {
+	//прямая дуга 0->2 дороже пути через вершину 1
+	graph := [][]trio{
+		{{1, 1, Normal}, {2, 5, Normal}},
+		{{2, 1, Boosting}},
+		{},
+	}
+	c := Constraint{BarrierLimit, 1}
+	path, dist, potential := DeijkstraVectorAlgorithmForBarrierWithPotential(graph, 0, 2, 1)
+	if err := VerifyCertificate(graph, c, 0, 2, potential, path, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyCertificate(graph, c, 0, 2, potential, []int{0, 2}, dist); err == nil {
+		t.Error("accepted a path that is not tight")
+	}
+	if err := VerifyCertificate(graph, c, 0, 2, potential, path, dist+1); err == nil {
+		t.Error("accepted a wrong distance")
+	}
+
+	forged := [][]int{{0, inf}, {1, inf}, {5, 3}}
+	if err := VerifyCertificate(graph, c, 0, 2, forged, []int{0, 2}, 3); err == nil {
+		t.Error("accepted a potential violating the triangle inequality")
+	}
+	lowered := [][]int{{0, inf}, {1, inf}, {5, 1}}
+	if err := VerifyCertificate(graph, c, 0, 2, lowered, path, 1); err == nil {
+		t.Error("accepted an underestimated distance")
+	}
+}
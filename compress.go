@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// compressionFormat описывает сжатый формат по сигнатуре в начале данных;
+// open == nil означает, что формат распознается, но распаковщика в стандартной библиотеке нет
+type compressionFormat struct {
+	name  string
+	magic []byte
+	open  func(r io.Reader) (io.Reader, error)
+}
+
+var compressionFormats = []compressionFormat{
+	{"gzip", []byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+	{"bzip2", []byte("BZh"), func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, nil},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, nil},
+}
+
+var ErrUnsupportedCompression = errors.New("неподдерживаемый формат сжатия")
+
+// detectCompression возвращает формат сжатия по первым байтам данных или nil для несжатых данных
+func detectCompression(head []byte) *compressionFormat {
+	for i := range compressionFormats {
+		if bytes.HasPrefix(head, compressionFormats[i].magic) {
+			return &compressionFormats[i]
+		}
+	}
+	return nil
+}
+
+// Decompress распознает сжатие по сигнатуре и возвращает поток распакованных данных;
+// несжатые данные возвращаются без изменений
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	//ошибку Peek не проверяем: короткий поток просто не совпадет ни с одной сигнатурой
+	head, _ := br.Peek(6)
+	format := detectCompression(head)
+	if format == nil {
+		return br, nil
+	}
+	if format.open == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, format.name)
+	}
+	return format.open(br)
+}
+
+// decompressBytes распаковывает данные, целиком находящиеся в памяти
+func decompressBytes(data []byte) ([]byte, error) {
+	if detectCompression(data) == nil {
+		return data, nil
+	}
+	r, err := Decompress(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+type inputFile struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenInput открывает файл и, если он сжат (например, graph.txt.gz), распаковывает его на лету
+func OpenInput(filename string) (io.ReadCloser, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	r, err := Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return inputFile{r, f}, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const smallGraphText = "3 3 1\n0 1 5 0\n1 2 3 2\n0 2 1 3\n"
+
+// smallGraphBzip2 - smallGraphText, сжатый утилитой bzip2 (в стандартной библиотеке нет упаковщика bzip2)
+var smallGraphBzip2 = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x47, 0x8b, 0x69, 0x8f, 0x00, 0x00,
+	0x0e, 0x58, 0x00, 0x00, 0x10, 0x40, 0x00, 0x7a, 0x00, 0x20, 0x00, 0x31, 0x0c, 0x00, 0xd4, 0xfd,
+	0x09, 0xa9, 0x2d, 0xec, 0x48, 0xca, 0x9c, 0x7b, 0x20, 0x12, 0x41, 0xf1, 0x77, 0x24, 0x53, 0x85,
+	0x09, 0x04, 0x78, 0xb6, 0x98, 0xf0,
+}
+
+func gzipBytes(t testing.TB, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressDetectsFormat(t *testing.T) {
+	for name, input := range map[string][]byte{
+		"plain": []byte(smallGraphText),
+		"gzip":  gzipBytes(t, []byte(smallGraphText)),
+		//несколько gzip-потоков подряд, как после cat a.gz b.gz
+		"gzip multistream": append(gzipBytes(t, []byte(smallGraphText[:10])), gzipBytes(t, []byte(smallGraphText[10:]))...),
+		"bzip2":            smallGraphBzip2,
+	} {
+		r, err := Decompress(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if data, err := io.ReadAll(r); err != nil || string(data) != smallGraphText {
+			t.Errorf("%s: got %q, %v", name, data, err)
+		}
+	}
+
+	for name, input := range map[string][]byte{
+		"zstd": {0x28, 0xb5, 0x2f, 0xfd, 0x24, 0x00},
+		"xz":   {0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00},
+	} {
+		if _, err := Decompress(bytes.NewReader(input)); !errors.Is(err, ErrUnsupportedCompression) {
+			t.Errorf("%s: got error %v", name, err)
+		}
+	}
+}
+
+func TestReadersAcceptCompressedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	adj, level := randomTestGraph(rand.New(rand.NewSource(44)), BarrierLimit)
+	var text bytes.Buffer
+	WriteGraph(&text, adj, BarrierLimit, level)
+	graph, gotLevel := ReadGraphForBarrierSpeedTest(write("graph.txt.gz", gzipBytes(t, text.Bytes())))
+	if want, _ := ReadGraphForBarrierSpeedTest(write("graph.txt", text.Bytes())); gotLevel != level || !reflect.DeepEqual(graph, want) {
+		t.Fatalf("text: got %v %d, want %v %d", graph, gotLevel, want, level)
+	}
+	if graph, _ := ReadGraphForMagnetSpeedTest(write("small.txt.bz2", smallGraphBzip2)); len(graph) != 3 || len(graph[0]) != 2 {
+		t.Fatalf("bzip2 text: got %v", graph)
+	}
+
+	g, c := NewGraph(adj), Constraint{BarrierLimit, level}
+	snapshot := snapshotBytes(t, g, c)
+	if loaded, _, err := LoadSnapshot(write("graph.csr.gz", gzipBytes(t, snapshot))); err != nil || !reflect.DeepEqual(loaded, g) {
+		t.Fatalf("snapshot file: %v", err)
+	}
+	if loaded, _, err := ReadSnapshot(bytes.NewReader(gzipBytes(t, snapshot))); err != nil || !reflect.DeepEqual(loaded, g) {
+		t.Fatalf("snapshot stream: %v", err)
+	}
+	if _, _, err := LoadSnapshot(write("graph.csr.zst", []byte{0x28, 0xb5, 0x2f, 0xfd, 0})); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("zstd snapshot: got error %v", err)
+	}
+
+	matrix := AllPairsDistances(adj, c)
+	var bin bytes.Buffer
+	WriteDistanceMatrixBinary(&bin, matrix)
+	if loaded, err := ReadDistanceMatrixBinary(bytes.NewReader(gzipBytes(t, bin.Bytes()))); err != nil || !reflect.DeepEqual(loaded, matrix) {
+		t.Fatalf("distance matrix: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions описывает таблицу дуг: имена столбцов (в файле без заголовка столбцы берутся по порядку
+// Source, Target, Weight, Kind), соответствие строк типам дуг и способ нумерации вершин
+type CSVOptions struct {
+	Source string
+	Target string
+	Weight string
+	Kind   string
+	//Kinds сопоставляет значения столбца Kind, приведенные к нижнему регистру, типам дуг; пустое значение - Normal
+	Kinds map[string]EdgeType
+	Comma rune
+	//NamedVertices: вершины заданы именами и нумеруются в порядке появления, иначе - номерами 0..n-1
+	NamedVertices bool
+	//N - число вершин для номеров; 0 означает наибольший номер плюс один, что допустимо только для плотной
+	//нумерации: номер не больше удвоенного числа дуг
+	N int
+}
+
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{
+		Source: "src",
+		Target: "dst",
+		Weight: "cost",
+		Kind:   "kind",
+		Kinds: map[string]EdgeType{
+			"normal": Normal, "0": Normal,
+			"closed": Closed, "1": Closed,
+			"boost": Boosting, "boosting": Boosting, "2": Boosting,
+			"barrier": Barrier, "3": Barrier,
+			"magnet": Magnet, "4": Magnet,
+		},
+		Comma: ',',
+	}
+}
+
+// CSVRowError - ошибка в одной строке таблицы; такая строка пропускается, чтение продолжается
+type CSVRowError struct {
+	Line int
+	Err  error
+}
+
+func (e CSVRowError) Error() string {
+	return fmt.Sprintf("строка %d: %v", e.Line, e.Err)
+}
+
+type CSVGraph struct {
+	Graph [][]trio
+	//Labels - имена вершин при NamedVertices, иначе nil
+	Labels []string
+	Errors []CSVRowError
+}
+
+type csvEdge struct {
+	from int
+	edge trio
+}
+
+// ReadCSVGraph читает таблицу дуг в те же списки смежности, что возвращает ReadGraphForMix.
+// Первая строка считается заголовком, если в ней есть столбцы Source, Target и Weight
+func ReadCSVGraph(r io.Reader, opts CSVOptions) (CSVGraph, error) {
+	r, err := Decompress(r)
+	if err != nil {
+		return CSVGraph{}, err
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Comma
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var result CSVGraph
+	var edges []csvEdge
+	index := make(map[string]int)
+	columns := [4]int{0, 1, 2, 3}
+	n := 0
+	//vertex проверяет имя или номер вершины и только затем регистрирует ее, чтобы пропущенная строка не добавляла вершин
+	vertex := func(id string, register bool) (int, error) {
+		if opts.NamedVertices {
+			if id == "" || strings.ContainsAny(id, " \t\r\n#") {
+				return 0, fmt.Errorf("недопустимое имя вершины %q", id)
+			}
+			v, ok := index[id]
+			if !ok && register {
+				v = len(result.Labels)
+				index[id] = v
+				result.Labels = append(result.Labels, id)
+			}
+			return v, nil
+		}
+		//номера вершин в Graph хранятся в int32
+		v, err := strconv.Atoi(id)
+		if err != nil || v < 0 || v > math.MaxInt32 || opts.N > 0 && v >= opts.N {
+			return 0, fmt.Errorf("недопустимый номер вершины %q", id)
+		}
+		if register && v >= n {
+			n = v + 1
+		}
+		return v, nil
+	}
+
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return result, err
+			}
+			result.Errors = append(result.Errors, CSVRowError{parseErr.Line, parseErr.Err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if first {
+			if header, ok := csvHeaderColumns(record, opts); ok {
+				columns = header
+				continue
+			}
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		field := func(i int) string {
+			if columns[i] < 0 || columns[i] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[columns[i]])
+		}
+		if columns[2] >= len(record) {
+			result.Errors = append(result.Errors, CSVRowError{line, fmt.Errorf("столбцов %d, а вес в столбце %d", len(record), columns[2]+1)})
+			continue
+		}
+		weight, err := strconv.Atoi(field(2))
+		if err != nil || weight < 0 {
+			result.Errors = append(result.Errors, CSVRowError{line, fmt.Errorf("недопустимый вес %q", field(2))})
+			continue
+		}
+		edgeType := Normal
+		if kind := field(3); kind != "" {
+			t, ok := opts.Kinds[strings.ToLower(kind)]
+			if !ok {
+				result.Errors = append(result.Errors, CSVRowError{line, fmt.Errorf("неизвестный тип дуги %q", kind)})
+				continue
+			}
+			edgeType = t
+		}
+		if _, err := vertex(field(0), false); err != nil {
+			result.Errors = append(result.Errors, CSVRowError{line, err})
+			continue
+		}
+		if _, err := vertex(field(1), false); err != nil {
+			result.Errors = append(result.Errors, CSVRowError{line, err})
+			continue
+		}
+		from, _ := vertex(field(0), true)
+		to, _ := vertex(field(1), true)
+		edges = append(edges, csvEdge{from, trio{to, weight, edgeType}})
+	}
+
+	if opts.NamedVertices {
+		n = len(result.Labels)
+	} else if opts.N > 0 {
+		n = opts.N
+	} else if n > 2*len(edges) {
+		//по разреженному номеру нельзя выделять память под все вершины до него
+		return result, fmt.Errorf("наибольший номер вершины %d при %d дугах: для разреженных номеров нужно указать число вершин", n-1, len(edges))
+	}
+	result.Graph = make([][]trio, n)
+	for _, e := range edges {
+		result.Graph[e.from] = append(result.Graph[e.from], e.edge)
+	}
+	return result, nil
+}
+
+// csvHeaderColumns находит номера столбцов по заголовку; столбец Kind необязателен
+func csvHeaderColumns(record []string, opts CSVOptions) ([4]int, bool) {
+	columns := [4]int{-1, -1, -1, -1}
+	for i, name := range record {
+		for j, want := range []string{opts.Source, opts.Target, opts.Weight, opts.Kind} {
+			if want != "" && strings.EqualFold(strings.TrimSpace(name), want) {
+				columns[j] = i
+			}
+		}
+	}
+	return columns, columns[0] >= 0 && columns[1] >= 0 && columns[2] >= 0
+}
+
+func CSVProgramm() {
+	var filename, outFilename, columns, named string
+
+	fmt.Print("Введите имя CSV-файла и имя выходного текстового файла: ")
+	fmt.Fscan(os.Stdin, &filename, &outFilename)
+	fmt.Print("Введите имена столбцов начала, конца, веса и типа через запятую (- по умолчанию src,dst,cost,kind): ")
+	fmt.Fscan(os.Stdin, &columns)
+	fmt.Print("Вершины заданы именами? (y/n): ")
+	fmt.Fscan(os.Stdin, &named)
+
+	opts := DefaultCSVOptions()
+	if columns != "-" {
+		names := strings.Split(columns, ",")
+		if len(names) != 4 {
+			log.Fatalf("нужно четыре имени столбцов: %q", columns)
+		}
+		opts.Source, opts.Target, opts.Weight, opts.Kind = names[0], names[1], names[2], names[3]
+	}
+	opts.NamedVertices = named == "y"
+	if !opts.NamedVertices {
+		fmt.Print("Введите число вершин (0 - наибольший номер плюс один): ")
+		fmt.Fscan(os.Stdin, &opts.N)
+	}
+
+	f, err := OpenInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	result, err := ReadCSVGraph(f, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, e := range result.Errors {
+		fmt.Println("Пропущена", e)
+	}
+
+	out, err := os.Create(outFilename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+	//граф только из обычных и закрытых дуг записывается как mix, для остальных тип ограничения и уровень
+	//спрашиваются: mix для них не определен
+	limit, level := MixLimit, 0
+	if !MixEdges(result.Graph) {
+		var limitName string
+		fmt.Print("В таблице есть ускоряющие, барьерные или магнитные дуги. Введите тип ограничения (bar, mag, magbar) и уровень: ")
+		fmt.Fscan(os.Stdin, &limitName, &level)
+		if limit, err = ParseLimitType(limitName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := WriteGraph(out, result.Graph, limit, level); err != nil {
+		log.Fatal(err)
+	}
+	if result.Labels != nil {
+		if err := WriteLabels(out, result.Labels); err != nil {
+			log.Fatal(err)
+		}
+	}
+	m, _ := CountEdges(result.Graph)
+	fmt.Println("Граф записан в файл", outFilename, ": вершин -", len(result.Graph), ", дуг -", m)
+}
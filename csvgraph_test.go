@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVGraphMatchesTextReader(t *testing.T) {
+	text := "4 5 1\n0 1 5 0\n1 2 3 1\n0 2 1 0\n2 3 2 1\n3 0 7 0\n"
+	input := "kind,cost,src,dst\nnormal,5,0,1\nClosed,3,1,2\n,1,0,2\nclosed,2,2,3\n0,7,3,0\n"
+	result, err := ReadCSVGraph(strings.NewReader(input), DefaultCSVOptions())
+	if err != nil || len(result.Errors) != 0 {
+		t.Fatalf("errors: %v %v", err, result.Errors)
+	}
+	if want := ReadGraphForMix(writeTempGraph(t, text)); !reflect.DeepEqual(result.Graph, want) {
+		t.Fatalf("got %v, want %v", result.Graph, want)
+	}
+}
+
+func TestReadCSVGraphOptions(t *testing.T) {
+	opts := DefaultCSVOptions()
+	opts.Source, opts.Target, opts.Weight, opts.Kind = "from", "to", "minutes", "link"
+	opts.Kinds = map[string]EdgeType{"rail": Normal, "express": Boosting, "gate": Barrier}
+	opts.Comma = ';'
+	opts.NamedVertices = true
+	input := "from;to;minutes;link\nMSK;TVR;90;express\nTVR;SPB;120;gate\nSPB;MSK;240;rail\n"
+	result, err := ReadCSVGraph(strings.NewReader(input), opts)
+	if err != nil || len(result.Errors) != 0 {
+		t.Fatalf("errors: %v %v", err, result.Errors)
+	}
+	want := [][]trio{{{1, 90, Boosting}}, {{2, 120, Barrier}}, {{0, 240, Normal}}}
+	if !reflect.DeepEqual(result.Graph, want) || !reflect.DeepEqual(result.Labels, []string{"MSK", "TVR", "SPB"}) {
+		t.Fatalf("got %v %v", result.Graph, result.Labels)
+	}
+
+	//без заголовка столбцы идут по порядку, столбец типа можно опустить
+	result, err = ReadCSVGraph(strings.NewReader("0,1,5\n1,2,3,boost\n"), DefaultCSVOptions())
+	want = [][]trio{{{1, 5, Normal}}, {{2, 3, Boosting}}, nil}
+	if err != nil || !reflect.DeepEqual(result.Graph, want) {
+		t.Fatalf("no header: got %v %v", result.Graph, err)
+	}
+}
+
+func TestReadCSVGraphReportsRowErrors(t *testing.T) {
+	input := "src,dst,cost,kind\n" +
+		"0,1,5,normal\n" +
+		"0,x,5,normal\n" +
+		"0,1,-2,normal\n" +
+		"0,1,5,teleport\n" +
+		"0,1\n" +
+		"0,1,5\"x,normal\n" +
+		"1,2,4,boost\n"
+	opts := DefaultCSVOptions()
+	opts.N = 3
+	result, err := ReadCSVGraph(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]trio{{{1, 5, Normal}}, {{2, 4, Boosting}}, nil}
+	if !reflect.DeepEqual(result.Graph, want) {
+		t.Fatalf("got %v, want %v", result.Graph, want)
+	}
+	wantErrors := []string{
+		"строка 3: недопустимый номер вершины",
+		"строка 4: недопустимый вес",
+		"строка 5: неизвестный тип дуги",
+		"строка 6: столбцов 2",
+		"строка 7:",
+	}
+	if len(result.Errors) != len(wantErrors) {
+		t.Fatalf("got errors %v", result.Errors)
+	}
+	for i, e := range result.Errors {
+		if !strings.HasPrefix(e.Error(), wantErrors[i]) {
+			t.Errorf("error %d: got %q, want prefix %q", i, e.Error(), wantErrors[i])
+		}
+	}
+
+	//пропущенная строка не добавляет вершин
+	opts = DefaultCSVOptions()
+	opts.NamedVertices = true
+	result, _ = ReadCSVGraph(strings.NewReader("A,B,1\nC,D,x\nA,bad name,1\n"), opts)
+	if !reflect.DeepEqual(result.Labels, []string{"A", "B"}) || len(result.Errors) != 2 {
+		t.Fatalf("got %v %v", result.Labels, result.Errors)
+	}
+}
+
+func TestReadCSVGraphRejectsSparseIDs(t *testing.T) {
+	if _, err := ReadCSVGraph(strings.NewReader("0,2000000000,1\n"), DefaultCSVOptions()); err == nil || !strings.Contains(err.Error(), "разреженных") {
+		t.Fatalf("got %v", err)
+	}
+	//с явным числом вершин такой номер - ошибка строки, а номер больше int32 отвергается всегда
+	opts := DefaultCSVOptions()
+	opts.N = 3
+	result, err := ReadCSVGraph(strings.NewReader("0,2000000000,1\n0,1,1\n"), opts)
+	if err != nil || len(result.Errors) != 1 || len(result.Graph) != 3 {
+		t.Fatalf("got %v %v", result, err)
+	}
+	result, _ = ReadCSVGraph(strings.NewReader("0,1,1\n0,9999999999,1\n"), DefaultCSVOptions())
+	if len(result.Errors) != 1 || len(result.Graph) != 2 {
+		t.Fatalf("got %v", result)
+	}
+}
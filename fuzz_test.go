@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fuzzTimeLimit = 2 * time.Second
+
+func addReaderSeeds(f *testing.F) {
+	f.Add([]byte("3 3 1\n0 1 5 0\n1 2 3 2\n0 2 1 3\n"))
+	f.Add([]byte("2 1 0\n0 1 1 1\n"))
+	f.Add([]byte("2 5 1\n0 7 1 0\n-1 1 1 0\n0 1 -4 0\n0 1 1 9\n"))
+	f.Add([]byte("1 1000000000 1\n0 0 1 0 5\n"))
+	f.Add([]byte("99999999999999 1 1\n0 0 1 0\n"))
+	f.Add([]byte(""))
+}
+
+func fuzzReader(f *testing.F, read func(filename string) [][]trio) {
+	addReaderSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		filename := filepath.Join(t.TempDir(), "graph.txt")
+		if err := os.WriteFile(filename, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		withTimeLimit(t, func() {
+			graph := read(filename)
+			for i, v := range graph {
+				for _, e := range v {
+					if e.EndPoint < 0 || e.EndPoint >= len(graph) || e.Weight < 0 {
+						t.Fatalf("vertex %d: invalid edge %v", i, e)
+					}
+				}
+			}
+		})
+	})
+}
+
+func FuzzReadGraphForMix(f *testing.F) {
+	fuzzReader(f, ReadGraphForMix)
+}
+
+func FuzzReadGraphForBarrier(f *testing.F) {
+	fuzzReader(f, func(filename string) [][]trio {
+		graph, _ := ReadGraphForBarrier(filename)
+		return graph
+	})
+}
+
+func FuzzReadGraphForMagnet(f *testing.F) {
+	fuzzReader(f, func(filename string) [][]trio {
+		graph, _ := ReadGraphForMagnet(filename)
+		return graph
+	})
+}
+
+func FuzzReadGraphForBarrierSpeedTest(f *testing.F) {
+	fuzzReader(f, func(filename string) [][]trio {
+		graph, _ := ReadGraphForBarrierSpeedTest(filename)
+		return graph
+	})
+}
+
+func FuzzReadGraphForMagnetSpeedTest(f *testing.F) {
+	fuzzReader(f, func(filename string) [][]trio {
+		graph, _ := ReadGraphForMagnetSpeedTest(filename)
+		return graph
+	})
+}
+
+// withTimeLimit роняет процесс, если run завис: зациклившийся решатель нельзя прервать иначе
+func withTimeLimit(t *testing.T, run func()) {
+	t.Helper()
+	timer := time.AfterFunc(fuzzTimeLimit, func() {
+		panic(fmt.Sprintf("%s: no result after %v", t.Name(), fuzzTimeLimit))
+	})
+	defer timer.Stop()
+	run()
+}
+
+var fuzzEdgeTypes = map[LimitType][]EdgeType{
+	MixLimit:           {Normal, Closed},
+	BarrierLimit:       {Normal, Boosting, Barrier},
+	MagnetLimit:        {Normal, Boosting, Magnet},
+	MagnetBarrierLimit: {Normal, Boosting, Magnet},
+}
+
+// fuzzGraph собирает граф из байтов: по четыре байта на дугу (начало, конец, вес, тип)
+func fuzzGraph(limit LimitType, n int, data []byte) [][]trio {
+	types := fuzzEdgeTypes[limit]
+	graph := make([][]trio, n)
+	for i := 0; i+3 < len(data); i += 4 {
+		from, to := int(data[i])%n, int(data[i+1])%n
+		graph[from] = append(graph[from], trio{to, int(data[i+2]), types[int(data[i+3])%len(types)]})
+	}
+	return graph
+}
+
+// minRealisedWeight возвращает минимальный вес, с которым последовательность вершин
+// проходится по правилам ограничения из уровня 0, или inf, если путь недопустим
+func minRealisedWeight(graph [][]trio, limit LimitType, level int, path []int) int {
+	levels := oracleLevels(limit, level)
+	cur := make([]int, levels)
+	for l := 1; l < levels; l++ {
+		cur[l] = inf
+	}
+	for i := 0; i+1 < len(path); i++ {
+		next := make([]int, levels)
+		for l := range next {
+			next[l] = inf
+		}
+		for l, d := range cur {
+			if d == inf {
+				continue
+			}
+			for _, e := range graph[path[i]] {
+				if e.EndPoint != path[i+1] {
+					continue
+				}
+				if to, ok := oracleStep(graph, limit, level, path[i], l, e); ok && d+e.Weight < next[to] {
+					next[to] = d + e.Weight
+				}
+			}
+		}
+		cur = next
+	}
+	best := inf
+	for _, d := range cur {
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func checkFuzzPath(t *testing.T, name string, graph [][]trio, limit LimitType, level int, finish int, path []int, dist int) {
+	t.Helper()
+	if dist == inf {
+		return
+	}
+	if len(path) == 0 || path[0] != 0 || path[len(path)-1] != finish {
+		t.Fatalf("%s: path %v does not lead from 0 to %d", name, path, finish)
+	}
+	if got := minRealisedWeight(graph, limit, level, path); got != dist {
+		t.Fatalf("%s: path %v realises weight %d, solver reported %d\n%v", name, path, got, dist, graph)
+	}
+}
+
+func FuzzSolvers(f *testing.F) {
+	f.Add(uint8(0), uint8(3), uint8(1), uint8(2), []byte{0, 1, 1, 0, 1, 2, 1, 1, 0, 2, 9, 0})
+	f.Add(uint8(1), uint8(3), uint8(2), uint8(2), []byte{0, 1, 5, 1, 1, 0, 5, 1, 0, 2, 1, 2})
+	f.Add(uint8(2), uint8(4), uint8(1), uint8(3), []byte{0, 1, 1, 1, 1, 2, 0, 2, 1, 3, 4, 0, 2, 3, 7, 1})
+	f.Add(uint8(3), uint8(4), uint8(0), uint8(3), []byte{0, 1, 0, 2, 1, 2, 3, 2, 1, 3, 4, 0, 2, 3, 0, 1})
+	f.Fuzz(func(t *testing.T, limitByte uint8, nByte uint8, levelByte uint8, finishByte uint8, edges []byte) {
+		limit := LimitType(limitByte % 4)
+		n := 1 + int(nByte)%8
+		level := int(levelByte) % 4
+		if limit == MagnetLimit && level == 0 {
+			level = 1
+		}
+		finish := int(finishByte) % n
+		if len(edges) > 4*32 {
+			edges = edges[:4*32]
+		}
+		graph := fuzzGraph(limit, n, edges)
+
+		withTimeLimit(t, func() {
+			path, dist := DeijkstraAlgorithm(MakeSimpleGraph(graph), 0, finish)
+			if dist != inf && (path[0] != 0 || path[len(path)-1] != finish) {
+				t.Fatalf("simple: path %v does not lead from 0 to %d", path, finish)
+			}
+
+			var auxGraph [][]duo
+			auxLevel := level
+			switch limit {
+			case MixLimit:
+				auxGraph, auxLevel = MakeAuxiliaryGraphForMix(graph), 1
+			case BarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForBarrier(graph, level)
+			case MagnetLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnet(graph, level)
+			case MagnetBarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnetBarrier(graph, level)
+			}
+			auxPath, auxDist := DeijkstraAlgorithmForAuxGraph(auxGraph, 0, finish, auxLevel, n)
+			sourcePath := make([]int, len(auxPath))
+			for i, v := range auxPath {
+				sourcePath[i] = v % n
+			}
+			checkFuzzPath(t, "aux", graph, limit, level, finish, sourcePath, auxDist)
+
+			if limit == MixLimit {
+				return
+			}
+			var vecPath []int
+			var vecDist int
+			switch limit {
+			case BarrierLimit:
+				vecPath, vecDist = DeijkstraVectorAlgorithmForBarrier(graph, 0, finish, level)
+			case MagnetLimit:
+				vecPath, vecDist = DeijkstraVectorAlgorithmForMagnet(graph, 0, finish, level)
+			case MagnetBarrierLimit:
+				vecPath, vecDist = DeijkstraVectorAlgorithmForMagnetBarrier(graph, 0, finish, level)
+			}
+			checkFuzzPath(t, "vector", graph, limit, level, finish, vecPath, vecDist)
+			if vecDist != auxDist {
+				t.Fatalf("vector %d, aux %d", vecDist, auxDist)
+			}
+		})
+	})
+}
+
+func TestReadEdgesSkipsInvalidEdges(t *testing.T) {
+	input := "0 9 1 0\n-1 0 1 0\n0 1 -3 0\n0 1 1 7\n1 0 2 2\n0 1 5 0\n"
+	graph := ReadEdges(strings.NewReader(input), 2, 6, false)
+	if len(graph[0]) != 1 || graph[0][0] != (trio{1, 5, Normal}) {
+		t.Fatalf("vertex 0: got %v", graph[0])
+	}
+	if len(graph[1]) != 1 || graph[1][0] != (trio{0, 2, Boosting}) {
+		t.Fatalf("vertex 1: got %v", graph[1])
+	}
+}
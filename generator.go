@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+)
+
+type GraphFamily int
+
+const (
+	RandomFamily GraphFamily = iota
+	GridFamily
+	LayeredDAGFamily
+	ScaleFreeFamily
+	HardFamily
+)
+
+var familyNames = [...]string{"random", "grid", "dag", "scalefree", "hard"}
+
+func (f GraphFamily) String() string {
+	if f < 0 || int(f) >= len(familyNames) {
+		return fmt.Sprintf("GraphFamily(%d)", int(f))
+	}
+	return familyNames[f]
+}
+
+func ParseGraphFamily(s string) (GraphFamily, error) {
+	for i, name := range familyNames {
+		if s == name {
+			return GraphFamily(i), nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестное семейство графов: %q", s)
+}
+
+type WeightDistribution int
+
+const (
+	UniformWeights WeightDistribution = iota
+	ExponentialWeights
+)
+
+// Proportions индексируется типом дуги (Normal, Closed, Boosting, Barrier, Magnet)
+type GeneratorConfig struct {
+	Limit        LimitType
+	Family       GraphFamily
+	N            int
+	M            int
+	Level        int
+	MinWeight    int
+	MaxWeight    int
+	Distribution WeightDistribution
+	Seed         int64
+	Proportions  [5]float64
+	Multigraph   bool
+}
+
+func DefaultProportions(limit LimitType) [5]float64 {
+	switch limit {
+	case MixLimit:
+		return [5]float64{Normal: 0.8, Closed: 0.2}
+	case BarrierLimit:
+		return [5]float64{Normal: 0.8, Boosting: 0.15, Barrier: 0.05}
+	case MagnetLimit, MagnetBarrierLimit:
+		return [5]float64{Normal: 0.75, Boosting: 0.15, Magnet: 0.1}
+	default:
+		panic(fmt.Sprintf("%v", limit))
+	}
+}
+
+func DefaultGeneratorConfig(limit LimitType) GeneratorConfig {
+	return GeneratorConfig{
+		Limit:       limit,
+		Family:      RandomFamily,
+		N:           10,
+		M:           30,
+		Level:       2,
+		MinWeight:   1,
+		MaxWeight:   10,
+		Seed:        1,
+		Proportions: DefaultProportions(limit),
+	}
+}
+
+type graphGenerator struct {
+	cfg   GeneratorConfig
+	rnd   *rand.Rand
+	graph [][]trio
+	pairs map[[2]int]bool
+}
+
+// GenerateGraph строит граф по конфигурации; для семейства hard путь ищется из 0 в последнюю вершину;
+// отрицательные веса недопустимы, так как на них неверны все варианты алгоритма Дейкстры
+func GenerateGraph(cfg GeneratorConfig) ([][]trio, error) {
+	if cfg.MaxWeight < cfg.MinWeight {
+		cfg.MinWeight, cfg.MaxWeight = cfg.MaxWeight, cfg.MinWeight
+	}
+	if cfg.MinWeight < 0 {
+		return nil, fmt.Errorf("минимальный вес дуги %d отрицателен", cfg.MinWeight)
+	}
+	if cfg.Family == HardFamily && cfg.Limit == BarrierLimit && cfg.Level < 1 {
+		//при нулевом уровне барьер проходим без ускорения, и обходить магистраль не нужно
+		return nil, fmt.Errorf("для семейства %v уровень ограничения %v должен быть не меньше 1", cfg.Family, cfg.Limit)
+	}
+	gen := &graphGenerator{cfg: cfg, rnd: rand.New(rand.NewSource(cfg.Seed))}
+	switch cfg.Family {
+	case RandomFamily:
+		gen.reset(cfg.N)
+		gen.random()
+	case GridFamily:
+		gen.reset(cfg.N)
+		gen.grid()
+	case LayeredDAGFamily:
+		gen.reset(cfg.N)
+		gen.layeredDAG()
+	case ScaleFreeFamily:
+		gen.reset(cfg.N)
+		gen.scaleFree()
+	case HardFamily:
+		gen.hard()
+	default:
+		panic(fmt.Sprintf("%v", cfg.Family))
+	}
+	return gen.graph, nil
+}
+
+func (gen *graphGenerator) reset(n int) {
+	if n < 0 {
+		n = 0
+	}
+	gen.graph = make([][]trio, n)
+	gen.pairs = make(map[[2]int]bool)
+}
+
+func (gen *graphGenerator) addEdge(from int, to int, weight int, edgeType EdgeType) bool {
+	if !gen.cfg.Multigraph {
+		if from == to || gen.pairs[[2]int{from, to}] {
+			return false
+		}
+		gen.pairs[[2]int{from, to}] = true
+	}
+	gen.graph[from] = append(gen.graph[from], trio{to, weight, edgeType})
+	return true
+}
+
+func (gen *graphGenerator) addRandomEdge(from int, to int) bool {
+	return gen.addEdge(from, to, gen.weight(), gen.edgeType())
+}
+
+func (gen *graphGenerator) weight() int {
+	lo, hi := gen.cfg.MinWeight, gen.cfg.MaxWeight
+	switch gen.cfg.Distribution {
+	case ExponentialWeights:
+		w := lo + int(gen.rnd.ExpFloat64()*float64(hi-lo)/3)
+		if w > hi {
+			w = hi
+		}
+		return w
+	default:
+		return lo + gen.rnd.Intn(hi-lo+1)
+	}
+}
+
+func (gen *graphGenerator) edgeType() EdgeType {
+	sum := 0.0
+	for _, p := range gen.cfg.Proportions {
+		sum += p
+	}
+	if sum <= 0 {
+		return Normal
+	}
+	x := gen.rnd.Float64() * sum
+	for t, p := range gen.cfg.Proportions {
+		if x < p {
+			return EdgeType(t)
+		}
+		x -= p
+	}
+	return Normal
+}
+
+func (gen *graphGenerator) random() {
+	n := len(gen.graph)
+	if n == 0 {
+		return
+	}
+	m := gen.cfg.M
+	if !gen.cfg.Multigraph && m > n*(n-1) {
+		m = n * (n - 1)
+	}
+	for added := 0; added < m; {
+		if gen.addRandomEdge(gen.rnd.Intn(n), gen.rnd.Intn(n)) {
+			added++
+		}
+	}
+}
+
+func (gen *graphGenerator) grid() {
+	n := len(gen.graph)
+	if n == 0 {
+		return
+	}
+	rows := int(math.Sqrt(float64(n)))
+	cols := (n + rows - 1) / rows
+	for v := 0; v < n; v++ {
+		if right := v + 1; v%cols != cols-1 && right < n {
+			gen.addRandomEdge(v, right)
+			gen.addRandomEdge(right, v)
+		}
+		if down := v + cols; down < n {
+			gen.addRandomEdge(v, down)
+			gen.addRandomEdge(down, v)
+		}
+	}
+}
+
+func (gen *graphGenerator) layeredDAG() {
+	n := len(gen.graph)
+	if n == 0 {
+		return
+	}
+	width := int(math.Sqrt(float64(n)))
+	degree := gen.cfg.M / n
+	if degree < 1 {
+		degree = 1
+	}
+	for v := 0; v+width < n; v++ {
+		layerStart := (v/width + 1) * width
+		layerEnd := layerStart + width
+		if layerEnd > n {
+			layerEnd = n
+		}
+		for i := 0; i < degree; i++ {
+			gen.addRandomEdge(v, layerStart+gen.rnd.Intn(layerEnd-layerStart))
+		}
+	}
+}
+
+func (gen *graphGenerator) scaleFree() {
+	n := len(gen.graph)
+	if n == 0 {
+		return
+	}
+	degree := gen.cfg.M / (2 * n)
+	if degree < 1 {
+		degree = 1
+	}
+	//вершина попадает в targets столько раз, какова ее степень
+	targets := make([]int, 0, 2*degree*n)
+	for v := 1; v < n; v++ {
+		if v <= degree {
+			for u := 0; u < v; u++ {
+				gen.addRandomEdge(v, u)
+				gen.addRandomEdge(u, v)
+				targets = append(targets, u, v)
+			}
+			continue
+		}
+		chosen := make(map[int]bool, degree)
+		for len(chosen) < degree {
+			chosen[targets[gen.rnd.Intn(len(targets))]] = true
+		}
+		for u := 0; u < v; u++ {
+			if chosen[u] {
+				gen.addRandomEdge(v, u)
+				gen.addRandomEdge(u, v)
+				targets = append(targets, u, v)
+			}
+		}
+	}
+}
+
+// hard строит магистраль из дешевых дуг, которую ограничение заставляет обходить через дорогие дуги
+func (gen *graphGenerator) hard() {
+	cfg := gen.cfg
+	cheap, heavy := cfg.MinWeight, cfg.MaxWeight
+	if cheap < 1 {
+		cheap = 1
+	}
+	if heavy < cheap {
+		heavy = cheap
+	}
+	level := cfg.Level
+	if level < 0 {
+		level = 0
+	}
+	if cfg.Limit == MagnetLimit && level < 1 {
+		level = 1
+	}
+
+	var segments, total int
+	switch cfg.Limit {
+	case MixLimit:
+		segments = (cfg.N - 1) / 2
+	case BarrierLimit:
+		segments = cfg.N - 1 - level
+	case MagnetLimit, MagnetBarrierLimit:
+		segments = (cfg.N - 1 + level) / 2
+	default:
+		panic(fmt.Sprintf("%v", cfg.Limit))
+	}
+	if segments < level+1 {
+		segments = level + 1
+	}
+	//mix допускает одну закрытую дугу, поэтому обход нужен только на магистрали хотя бы из двух закрытых дуг
+	if cfg.Limit == MixLimit && segments < 2 {
+		segments = 2
+	}
+	switch cfg.Limit {
+	case MixLimit:
+		total = 1 + 2*segments
+	case BarrierLimit:
+		total = 1 + level + segments
+	default:
+		total = 1 + 2*segments - level
+	}
+	n := total
+	if cfg.N > n {
+		n = cfg.N
+	}
+	gen.reset(n)
+
+	next := 1
+	vertex := func() int {
+		next++
+		return next - 1
+	}
+	backbone := make([]int, segments+1)
+	for i := 1; i <= segments; i++ {
+		backbone[i] = vertex()
+	}
+
+	switch cfg.Limit {
+	case MixLimit:
+		for i := 0; i < segments; i++ {
+			d := vertex()
+			gen.addEdge(backbone[i], backbone[i+1], cheap, Closed)
+			gen.addEdge(backbone[i], d, heavy, Normal)
+			gen.addEdge(d, backbone[i+1], heavy, Normal)
+		}
+	case BarrierLimit:
+		//петля из level ускоряющих дуг, возвращающая в стартовую вершину
+		prev := 0
+		for i := 0; i < level; i++ {
+			s := vertex()
+			gen.addEdge(prev, s, heavy, Boosting)
+			prev = s
+		}
+		if level > 0 {
+			gen.addEdge(prev, 0, heavy, Normal)
+		}
+		for i := 0; i < segments-1; i++ {
+			gen.addEdge(backbone[i], backbone[i+1], cheap, Normal)
+		}
+		gen.addEdge(backbone[segments-1], backbone[segments], cheap, Barrier)
+	case MagnetLimit, MagnetBarrierLimit:
+		detourType := Boosting
+		if cfg.Limit == MagnetBarrierLimit {
+			detourType = Normal
+		}
+		for i := 0; i < segments; i++ {
+			if i < level {
+				gen.addEdge(backbone[i], backbone[i+1], cheap, Boosting)
+				continue
+			}
+			d := vertex()
+			gen.addEdge(backbone[i], backbone[i+1], cheap, detourType)
+			gen.addEdge(backbone[i], d, heavy, Magnet)
+			gen.addEdge(d, backbone[i+1], heavy, detourType)
+		}
+	}
+
+	//оставшиеся вершины соединяем дугами тяжелее любого пути по конструкции
+	noiseWeight := heavy*2*n + 1
+	for v := total; v < n; v++ {
+		gen.addEdge(v, gen.rnd.Intn(n), noiseWeight, Normal)
+	}
+	SwapVertices(gen.graph, backbone[segments], n-1)
+}
+
+func SwapVertices(graph [][]trio, a int, b int) {
+	if a == b {
+		return
+	}
+	for _, v := range graph {
+		for i := range v {
+			switch v[i].EndPoint {
+			case a:
+				v[i].EndPoint = b
+			case b:
+				v[i].EndPoint = a
+			}
+		}
+	}
+	graph[a], graph[b] = graph[b], graph[a]
+}
+
+func CountEdges(graph [][]trio) (int, int) {
+	m, closed := 0, 0
+	for _, v := range graph {
+		for _, e := range v {
+			m++
+			if e.EdgeType == Closed {
+				closed++
+			}
+		}
+	}
+	return m, closed
+}
+
+// WriteGraph записывает граф в текстовом формате с типом ограничения в заголовке;
+// для mix третье число заголовка - число запрещенных дуг, а дуги других типов недопустимы
+func WriteGraph(w io.Writer, graph [][]trio, limit LimitType, level int) error {
+	m, closed := CountEdges(graph)
+	if limit == MixLimit {
+		if !MixEdges(graph) {
+			return fmt.Errorf("граф с ускоряющими, барьерными или магнитными дугами нельзя записать с ограничением %v", limit)
+		}
+		level = closed
+	}
+	h := GraphHeader{len(graph), m, level, limit, true}
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	return writeGraphText(w, graph, h)
+}
+
+// writeGraphText записывает заголовок h как есть: тип ограничения - только если он объявлен
+func writeGraphText(w io.Writer, graph [][]trio, h GraphHeader) error {
+	bw := bufio.NewWriter(w)
+	if h.Declared {
+		fmt.Fprintln(bw, h.N, h.M, h.Level, h.Kind)
+	} else {
+		fmt.Fprintln(bw, h.N, h.M, h.Level)
+	}
+	for i, v := range graph {
+		for _, e := range v {
+			fmt.Fprintln(bw, i, e.EndPoint, e.Weight, int(e.EdgeType))
+		}
+	}
+	return bw.Flush()
+}
+
+func WriteGraphFile(filename string, graph [][]trio, limit LimitType, level int) {
+	f, err := os.Create(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := WriteGraph(f, graph, limit, level); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func GenerateProgramm() {
+	var limitName, familyName, distributionName, filename string
+
+	fmt.Print("Введите тип ограничения (mix, bar, mag, magbar): ")
+	fmt.Fscan(os.Stdin, &limitName)
+	limit, err := ParseLimitType(limitName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg := DefaultGeneratorConfig(limit)
+
+	fmt.Print("Введите семейство графа (random, grid, dag, scalefree, hard): ")
+	fmt.Fscan(os.Stdin, &familyName)
+	if cfg.Family, err = ParseGraphFamily(familyName); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print("Введите число вершин и число дуг: ")
+	fmt.Fscan(os.Stdin, &cfg.N, &cfg.M)
+	if limit != MixLimit {
+		fmt.Print("Введите уровень ограничения: ")
+		fmt.Fscan(os.Stdin, &cfg.Level)
+	}
+	fmt.Print("Введите минимальный и максимальный вес дуги: ")
+	fmt.Fscan(os.Stdin, &cfg.MinWeight, &cfg.MaxWeight)
+	fmt.Print("Введите распределение весов (uniform, exp): ")
+	fmt.Fscan(os.Stdin, &distributionName)
+	if distributionName == "exp" {
+		cfg.Distribution = ExponentialWeights
+	}
+	fmt.Print("Введите доли дуг типов Normal, Closed, Boosting, Barrier, Magnet (0 0 0 0 0 - по умолчанию): ")
+	var proportions [5]float64
+	fmt.Fscan(os.Stdin, &proportions[0], &proportions[1], &proportions[2], &proportions[3], &proportions[4])
+	if proportions != [5]float64{} {
+		cfg.Proportions = proportions
+	}
+	fmt.Print("Введите seed: ")
+	fmt.Fscan(os.Stdin, &cfg.Seed)
+	fmt.Print("Введите имя выходного файла: ")
+	fmt.Fscan(os.Stdin, &filename)
+
+	graph, err := GenerateGraph(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	WriteGraphFile(filename, graph, limit, cfg.Level)
+	m, _ := CountEdges(graph)
+	fmt.Println("Граф записан в файл", filename, ": вершин -", len(graph), ", дуг -", m)
+}
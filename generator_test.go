@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// generatorEdgeTypes - типы дуг, которые генератор выдает для ограничения при пропорциях по умолчанию
+var generatorEdgeTypes = map[LimitType][]EdgeType{
+	MixLimit:           {Normal, Closed},
+	BarrierLimit:       {Normal, Boosting, Barrier},
+	MagnetLimit:        {Normal, Boosting, Magnet},
+	MagnetBarrierLimit: {Normal, Boosting, Magnet},
+}
+
+func TestGenerateGraphFamilies(t *testing.T) {
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for family := range familyNames {
+			for _, n := range []int{0, 1, 2, 17, 60} {
+				cfg := DefaultGeneratorConfig(limit)
+				cfg.Family = GraphFamily(family)
+				cfg.N, cfg.M = n, 4*n
+				cfg.Seed = int64(n) + 26
+				graph, err := GenerateGraph(cfg)
+				if err != nil {
+					t.Fatalf("%v %v n=%d: %v", limit, cfg.Family, n, err)
+				}
+
+				//для hard вершин может быть больше: магистраль строится не короче уровня
+				if cfg.Family == HardFamily && len(graph) < n || cfg.Family != HardFamily && len(graph) != n {
+					t.Fatalf("%v %v n=%d: got %d vertices", limit, cfg.Family, n, len(graph))
+				}
+				for v, edges := range graph {
+					for _, e := range edges {
+						if e.EndPoint < 0 || e.EndPoint >= len(graph) || e.Weight < 0 {
+							t.Fatalf("%v %v n=%d: invalid edge %d %v", limit, cfg.Family, n, v, e)
+						}
+						if !containsEdgeType(generatorEdgeTypes[limit], e.EdgeType) {
+							t.Fatalf("%v %v n=%d: edge type %d not allowed", limit, cfg.Family, n, e.EdgeType)
+						}
+					}
+				}
+				if again, _ := GenerateGraph(cfg); !reflect.DeepEqual(graph, again) {
+					t.Fatalf("%v %v n=%d: same seed gave different graphs", limit, cfg.Family, n)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateHardFamilyForcesDetour(t *testing.T) {
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for _, n := range []int{0, 1, 2, 17, 60} {
+			for _, level := range []int{0, 1, 2, 5} {
+				cfg := DefaultGeneratorConfig(limit)
+				cfg.Family = HardFamily
+				cfg.N, cfg.Level = n, level
+				graph, err := GenerateGraph(cfg)
+				if limit == BarrierLimit && level == 0 {
+					if err == nil {
+						t.Errorf("bar n=%d: expected error for level 0", n)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				//без ограничения дешевая магистраль проходима целиком, а с ограничением ее приходится обходить
+				if level < 1 && limit == MagnetLimit {
+					level = 1
+				}
+				finish := len(graph) - 1
+				_, free := DeijkstraAlgorithm(MakeSimpleGraph(graph), 0, finish)
+				_, constrained := RouteOnGraph(NewGraph(graph), Constraint{limit, level}, 0, finish)
+				if constrained == int(^uint(0)>>1) || constrained <= free {
+					t.Errorf("%v n=%d level=%d: constrained %d, unconstrained %d", limit, n, level, constrained, free)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateGraphRejectsNegativeWeights(t *testing.T) {
+	cfg := DefaultGeneratorConfig(BarrierLimit)
+	cfg.MinWeight, cfg.MaxWeight = -3, 5
+	if _, err := GenerateGraph(cfg); err == nil {
+		t.Fatal("expected error for negative weights")
+	}
+	//границы можно задать в любом порядке
+	cfg.MinWeight, cfg.MaxWeight = 5, 0
+	if _, err := GenerateGraph(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func containsEdgeType(types []EdgeType, t EdgeType) bool {
+	for _, allowed := range types {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// WithCoordinates возвращает граф с координатами вершин (X - долгота, Y - широта в градусах);
+// координаты NaN означают, что положение вершины неизвестно
+func (g *Graph) WithCoordinates(coords []Coordinates) (*Graph, error) {
+	if len(coords) != g.N() {
+		return nil, fmt.Errorf("координаты заданы для %d вершин, а вершин %d", len(coords), g.N())
+	}
+	for v, c := range coords {
+		if !math.IsNaN(c.X) && !math.IsNaN(c.Y) && !validLonLat(c.X, c.Y) {
+			return nil, fmt.Errorf("недопустимые координаты вершины %d: %v", v, c)
+		}
+	}
+	located := *g
+	located.coords = coords
+	return &located, nil
+}
+
+// Coordinates возвращает координаты вершин или nil, если граф без координат
+func (g *Graph) Coordinates() []Coordinates {
+	return g.coords
+}
+
+// WriteCoordinates дописывает к графу в текстовом формате раздел coords с известными координатами вершин
+func WriteCoordinates(w io.Writer, coords []Coordinates) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "coords")
+	for v, c := range coords {
+		if !math.IsNaN(c.X) && !math.IsNaN(c.Y) {
+			fmt.Fprintln(bw, v, c.X, c.Y)
+		}
+	}
+	return bw.Flush()
+}
+
+// RouteSegment - дуга пути с уровнями до и после ее прохождения
+type RouteSegment struct {
+	From      int
+	To        int
+	Weight    int
+	EdgeType  EdgeType
+	FromLevel int
+	ToLevel   int
+}
+
+// segmentChoice - дуга, которой путь приходит на уровень шага, и уровень перед ней
+type segmentChoice struct {
+	edge  trio
+	level int
+}
+
+// RouteSegments восстанавливает дуги и уровни пути, заданного последовательностью вершин
+// (как его возвращают векторные алгоритмы или RouteOnGraph): среди параллельных дуг FindEdgeInGraph
+// выбирает допустимую при ограничении c последовательность наименьшего веса, а уровни берутся
+// из ValidatePath, поэтому совпадают с проверкой пути; путь начинается на уровне 0
+func RouteSegments(g *Graph, c Constraint, path []int) ([]RouteSegment, error) {
+	for _, v := range path {
+		if v < 0 || v >= g.N() {
+			return nil, fmt.Errorf("вершина %d пути вне графа", v)
+		}
+	}
+	adj := g.AdjacencyList()
+	levels := c.Levels()
+	best := make([]int, levels)
+	for l := range best {
+		best[l] = int(^uint(0) >> 1)
+	}
+	best[0] = 0
+	choices := make([][]segmentChoice, 0, len(path))
+	for i := 0; i+1 < len(path); i++ {
+		next := make([]int, levels)
+		choice := make([]segmentChoice, levels)
+		for l := range next {
+			next[l] = int(^uint(0) >> 1)
+		}
+		for l, d := range best {
+			if d == int(^uint(0)>>1) {
+				continue
+			}
+			for nl := range next {
+				if e, ok := FindEdgeInGraph(adj, c, path[i], l, path[i+1], nl); ok && d+e.Weight < next[nl] {
+					next[nl] = d + e.Weight
+					choice[nl] = segmentChoice{e, l}
+				}
+			}
+		}
+		best = next
+		choices = append(choices, choice)
+	}
+
+	level := -1
+	for l, d := range best {
+		if d != int(^uint(0)>>1) && (level == -1 || d < best[level]) {
+			level = l
+		}
+	}
+	if level == -1 {
+		return nil, fmt.Errorf("путь %v не проходится при ограничении %v", path, c.Type)
+	}
+	edges := make([]fourths, len(choices))
+	for i := len(choices) - 1; i >= 0; i-- {
+		ch := choices[i][level]
+		edges[i] = fourths{path[i], path[i+1], ch.edge.Weight, ch.edge.EdgeType}
+		level = ch.level
+	}
+
+	check := ValidatePath(adj, c, edges)
+	if !check.Valid {
+		return nil, fmt.Errorf("путь %v: %s", path, check.Reason)
+	}
+	segments := make([]RouteSegment, len(edges))
+	for i, e := range edges {
+		segments[i] = RouteSegment{e.StartPoint, e.EndPoint, e.Weight, e.EdgeType, check.Levels[i], check.Levels[i+1]}
+	}
+	return segments, nil
+}
+
+type geoJSONGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func lineString(points ...Coordinates) geoJSONGeometry {
+	line := geoJSONGeometry{Type: "LineString", Coordinates: make([][2]float64, len(points))}
+	for i, p := range points {
+		line.Coordinates[i] = [2]float64{p.X, p.Y}
+	}
+	return line
+}
+
+// WriteRouteGeoJSON пишет путь как FeatureCollection: первая фигура - LineString всего маршрута
+// с общей длиной, за ней по LineString на каждую дугу со свойствами edgeType, weight и level.
+// path - последовательность вершин; путь вспомогательного графа сначала переводится в вершины (v % n)
+func WriteRouteGeoJSON(w io.Writer, g *Graph, c Constraint, path []int) error {
+	if g.coords == nil {
+		return fmt.Errorf("у графа нет координат вершин")
+	}
+	if len(path) < 2 {
+		return fmt.Errorf("в маршруте %v нет ни одной дуги", path)
+	}
+	segments, err := RouteSegments(g, c, path)
+	if err != nil {
+		return err
+	}
+	points := make([]Coordinates, len(path))
+	for i, v := range path {
+		points[i] = g.coords[v]
+		if math.IsNaN(points[i].X) || math.IsNaN(points[i].Y) {
+			return fmt.Errorf("у вершины %s нет координат", g.Label(v))
+		}
+	}
+
+	dist := 0
+	for _, s := range segments {
+		dist += s.Weight
+	}
+	route := geoJSONFeature{Type: "Feature", Geometry: lineString(points...), Properties: map[string]interface{}{
+		"start":      g.Label(path[0]),
+		"finish":     g.Label(path[len(path)-1]),
+		"distance":   dist,
+		"constraint": c.Type.String(),
+		"limitLevel": c.Level,
+	}}
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{route}}
+	for i, s := range segments {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: lineString(points[i], points[i+1]),
+			Properties: map[string]interface{}{
+				"step":         i,
+				"from":         g.Label(s.From),
+				"to":           g.Label(s.To),
+				"edgeType":     int(s.EdgeType),
+				"edgeTypeName": edgeTypeNames[s.EdgeType],
+				"weight":       s.Weight,
+				"level":        s.FromLevel,
+				"nextLevel":    s.ToLevel,
+			},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collection)
+}
+
+func WriteRouteGeoJSONFile(filename string, g *Graph, c Constraint, path []int) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteRouteGeoJSON(f, g, c, path); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
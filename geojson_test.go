@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const geoGraphText = `3 3 1
+0 1 5 2
+1 2 3 3
+0 2 20 0
+labels
+0 MSK
+1 TVR
+2 SPB
+coords
+0 37.6173 55.7558
+1 35.9006 56.8587
+2 30.3351 59.9343
+`
+
+func TestRouteSegmentsMatchSolverDistance(t *testing.T) {
+	rnd := rand.New(rand.NewSource(46))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 300; i++ {
+			adj, level := randomTestGraph(rnd, limit)
+			g, c := NewGraph(adj), Constraint{limit, level}
+			start, finish := rnd.Intn(len(adj)), rnd.Intn(len(adj))
+			path, dist := RouteOnGraph(g, c, start, finish)
+			if dist == inf {
+				continue
+			}
+			segments, err := RouteSegments(g, c, path)
+			if err != nil {
+				t.Fatalf("%v: %v", limit, err)
+			}
+			weight, level := 0, 0
+			for j, s := range segments {
+				if s.From != path[j] || s.To != path[j+1] || s.FromLevel != level {
+					t.Fatalf("%v: segment %d: %+v, path %v", limit, j, s, path)
+				}
+				if next, ok := NextLevel(c, s.EdgeType, level, g.MagnetVertex(s.From)); !ok || next != s.ToLevel {
+					t.Fatalf("%v: segment %d: %+v is not allowed", limit, j, s)
+				}
+				weight, level = weight+s.Weight, s.ToLevel
+			}
+			if weight != dist {
+				t.Fatalf("%v: segments weigh %d, solver distance %d", limit, weight, dist)
+			}
+		}
+	}
+}
+
+func TestWriteRouteGeoJSON(t *testing.T) {
+	g, header, err := LoadGraphFile(writeTempGraph(t, geoGraphText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Constraint{BarrierLimit, header.Level}
+	path, _ := RouteOnGraph(g, c, 0, 2)
+	var buf bytes.Buffer
+	if err := WriteRouteGeoJSON(&buf, g, c, path); err != nil {
+		t.Fatal(err)
+	}
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates [][2]float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 3 {
+		t.Fatalf("got %s", buf.String())
+	}
+	route := collection.Features[0]
+	if route.Geometry.Type != "LineString" || len(route.Geometry.Coordinates) != 3 ||
+		route.Geometry.Coordinates[2] != [2]float64{30.3351, 59.9343} || route.Properties["distance"] != 8.0 {
+		t.Fatalf("route: %+v", route)
+	}
+	want := []map[string]interface{}{
+		{"step": 0.0, "from": "MSK", "to": "TVR", "edgeType": 2.0, "edgeTypeName": "Boosting", "weight": 5.0, "level": 0.0, "nextLevel": 1.0},
+		{"step": 1.0, "from": "TVR", "to": "SPB", "edgeType": 3.0, "edgeTypeName": "Barrier", "weight": 3.0, "level": 1.0, "nextLevel": 0.0},
+	}
+	for i, w := range want {
+		if got := collection.Features[i+1].Properties; !reflect.DeepEqual(got, w) {
+			t.Errorf("segment %d: got %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestCoordinatesSection(t *testing.T) {
+	g, _, err := LoadGraphFile(writeTempGraph(t, "3 0 0\ncoords\n0 37.6 55.7\n2 -0.1 51.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords := g.Coordinates()
+	if coords[0] != (Coordinates{37.6, 55.7}) || !math.IsNaN(coords[1].X) || coords[2] != (Coordinates{-0.1, 51.5}) {
+		t.Fatalf("got %v", coords)
+	}
+	var buf bytes.Buffer
+	WriteGraph(&buf, g.AdjacencyList(), BarrierLimit, 0)
+	WriteCoordinates(&buf, coords)
+	again, _, err := LoadGraphFile(writeTempGraph(t, buf.String()))
+	if err != nil || again.Coordinates()[2] != coords[2] || !math.IsNaN(again.Coordinates()[1].Y) {
+		t.Fatalf("round trip: %v %v", again.Coordinates(), err)
+	}
+	//у вершины 1 нет координат, поэтому маршрут через нее не экспортируется
+	located, _ := NewGraph([][]trio{{{1, 1, Normal}}, nil, nil}).WithCoordinates(coords)
+	if err := WriteRouteGeoJSON(&buf, located, Constraint{BarrierLimit, 0}, []int{0, 1}); err == nil {
+		t.Fatal("vertex without coordinates: no error")
+	}
+
+	for text, want := range map[string]string{
+		"2 0 0\ncoords\n0 200 10\n":      "недопустимые координаты",
+		"2 0 0\ncoords\n0 10\n":          "долгота и широта",
+		"2 0 0\ncoords\n0 1 1\ncoords\n": "повторный раздел",
+	} {
+		if _, _, err := LoadGraphFile(writeTempGraph(t, text)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got error %v, want %q", text, err, want)
+		}
+	}
+}
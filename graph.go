@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Graph - неизменяемый граф в формате CSR: дуги вершины v занимают индексы
+// Offsets[v]..Offsets[v+1]-1 в массивах Targets, Weights и Types
+type Graph struct {
+	Offsets []int
+	Targets []int32
+	Weights []int
+	Types   []uint8
+	magnet  []bool
+	labels  []string
+	index   map[string]int
+	coords  []Coordinates
+	//reversed - обращенный граф, общий для копий графа с другими именами или координатами вершин
+	reversed *reversedCache
+}
+
+type reversedCache struct {
+	once  sync.Once
+	graph *Graph
+}
+
+// NewGraph упаковывает списки смежности, полученные от читателей (ReadGraphForBarrier и др.)
+func NewGraph(adj [][]trio) *Graph {
+	m, _ := CountEdges(adj)
+	g := &Graph{
+		Offsets: make([]int, len(adj)+1),
+		Targets: make([]int32, 0, m),
+		Weights: make([]int, 0, m),
+		Types:   make([]uint8, 0, m),
+	}
+	for v, edges := range adj {
+		for _, e := range edges {
+			if e.EdgeType < Normal || e.EdgeType > Magnet {
+				panic(fmt.Sprintf("%v", e))
+			}
+			g.Targets = append(g.Targets, int32(e.EndPoint))
+			g.Weights = append(g.Weights, e.Weight)
+			g.Types = append(g.Types, uint8(e.EdgeType))
+		}
+		g.Offsets[v+1] = len(g.Targets)
+	}
+	g.indexMagnetVertices()
+	g.reversed = new(reversedCache)
+	return g
+}
+
+// indexMagnetVertices отмечает вершины, из которых выходят магнитные дуги
+func (g *Graph) indexMagnetVertices() {
+	g.magnet = make([]bool, g.N())
+	for v := range g.magnet {
+		for i := g.Offsets[v]; i < g.Offsets[v+1]; i++ {
+			if EdgeType(g.Types[i]) == Magnet {
+				g.magnet[v] = true
+				break
+			}
+		}
+	}
+}
+
+func (g *Graph) N() int {
+	return len(g.Offsets) - 1
+}
+
+func (g *Graph) M() int {
+	return len(g.Targets)
+}
+
+// Edge возвращает дугу с индексом i в упакованных массивах
+func (g *Graph) Edge(i int) trio {
+	return trio{int(g.Targets[i]), g.Weights[i], EdgeType(g.Types[i])}
+}
+
+// MagnetVertex сообщает, выходят ли из вершины v магнитные дуги
+func (g *Graph) MagnetVertex(v int) bool {
+	return g.magnet[v]
+}
+
+// Reversed возвращает обращенный граф в формате CSR: дуги вершины v - входящие в v дуги g, а Targets -
+// их начальные вершины; MagnetVertex у него тот же, что у g. Граф строится при первом вызове и
+// сохраняется, так как g не меняется, а от ограничения обращение не зависит
+func (g *Graph) Reversed() *Graph {
+	if g.reversed == nil {
+		return g.reverse()
+	}
+	g.reversed.once.Do(func() {
+		g.reversed.graph = g.reverse()
+	})
+	return g.reversed.graph
+}
+
+func (g *Graph) reverse() *Graph {
+	n, m := g.N(), g.M()
+	r := &Graph{
+		Offsets: make([]int, n+1),
+		Targets: make([]int32, m),
+		Weights: make([]int, m),
+		Types:   make([]uint8, m),
+		magnet:  g.magnet,
+	}
+	for _, to := range g.Targets {
+		r.Offsets[to+1]++
+	}
+	for v := 0; v < n; v++ {
+		r.Offsets[v+1] += r.Offsets[v]
+	}
+	next := append([]int(nil), r.Offsets[:n]...)
+	for v := 0; v < n; v++ {
+		for i := g.Offsets[v]; i < g.Offsets[v+1]; i++ {
+			j := next[g.Targets[i]]
+			next[g.Targets[i]]++
+			r.Targets[j], r.Weights[j], r.Types[j] = int32(v), g.Weights[i], g.Types[i]
+		}
+	}
+	return r
+}
+
+// AdjacencyList восстанавливает списки смежности для функций, работающих с [][]trio
+func (g *Graph) AdjacencyList() [][]trio {
+	adj := make([][]trio, g.N())
+	for v := range adj {
+		for i := g.Offsets[v]; i < g.Offsets[v+1]; i++ {
+			adj[v] = append(adj[v], g.Edge(i))
+		}
+	}
+	return adj
+}
+
+// AuxiliaryGraph строит вспомогательный граф для ограничения c
+func (g *Graph) AuxiliaryGraph(c Constraint) [][]duo {
+	switch c.Type {
+	case MixLimit:
+		return MakeAuxiliaryGraphForMix(g.AdjacencyList())
+	case BarrierLimit:
+		return MakeAuxiliaryGraphForBarrier(g.AdjacencyList(), c.Level)
+	case MagnetLimit:
+		return MakeAuxiliaryGraphForMagnet(g.AdjacencyList(), c.Level)
+	case MagnetBarrierLimit:
+		return MakeAuxiliaryGraphForMagnetBarrier(g.AdjacencyList(), c.Level)
+	}
+	panic(fmt.Sprintf("%v", c.Type))
+}
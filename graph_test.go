@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGraphRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(15))
+	for i := 0; i < 200; i++ {
+		adj, _ := randomTestGraph(rnd, BarrierLimit)
+		g := NewGraph(adj)
+		m, _ := CountEdges(adj)
+		if g.N() != len(adj) || g.M() != m {
+			t.Fatalf("got n=%d m=%d, want n=%d m=%d", g.N(), g.M(), len(adj), m)
+		}
+		back := g.AdjacencyList()
+		for v := range adj {
+			if len(adj[v]) == 0 && len(back[v]) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(back[v], adj[v]) {
+				t.Fatalf("vertex %d: got %v, want %v", v, back[v], adj[v])
+			}
+		}
+	}
+}
+
+func TestSolversOnGraphMatchOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(16))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 500; i++ {
+			adj, level := randomTestGraph(rnd, limit)
+			g, c := NewGraph(adj), Constraint{limit, level}
+			n := g.N()
+			start, finish := rnd.Intn(n), rnd.Intn(n)
+			want := oracleDistance(adj, limit, level, start, finish)
+
+			dists := map[string]int{}
+			_, dists["aux"] = DeijkstraAlgorithmForAuxGraphWithLevels(g.AuxiliaryGraph(c), start, finish, c.Levels()-1, n, LevelSpec{})
+			_, dists["astar"] = AStarVectorAlgorithmOnGraph(g, c, start, finish, nil)
+			_, dists["bidirectional"] = BidirectionalDeijkstraAlgorithmOnGraph(g, c, start, finish)
+			dists["allpairs"] = AllPairsDistancesOnGraph(g, c)[start][finish]
+			dists["yen"] = inf
+			if paths := YenKShortestPathsOnGraph(g, c, start, finish, 1, DefaultYenOptions()); len(paths) > 0 {
+				dists["yen"] = paths[0].Dist
+			}
+			if limit != MixLimit {
+				_, dists["vector"] = DeijkstraVectorAlgorithmOnGraph(g, c, start, finish, LevelSpec{})
+				dists["multisource"] = MultiSourceVectorAlgorithmOnGraph(g, c, []Source{{start, 0, 0}}, []int{finish}).Dist
+			}
+			for name, got := range dists {
+				if got != want {
+					t.Fatalf("%v level %d from %d to %d: %s %d, oracle %d\n%v", limit, level, start, finish, name, got, want, adj)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Ключи GraphML: вес и тип дуги - данные дуг, уровень ограничения (для mix - число запрещенных дуг,
+// как в заголовке текстового формата) и необязательный тип ограничения - данные графа,
+// имя вершины и ее долгота и широта - данные вершин.
+// При чтении ключи ищутся по attr.name, поэтому подходят файлы из редакторов с собственными id ключей
+const (
+	graphMLNamespace      = "http://graphml.graphdrawing.org/xmlns"
+	graphMLWeightName     = "weight"
+	graphMLEdgeTypeName   = "EdgeType"
+	graphMLLevelName      = "level"
+	graphMLConstraintName = "constraint"
+	graphMLLabelName      = "label"
+	graphMLLonName        = "lon"
+	graphMLLatName        = "lat"
+)
+
+type graphMLKey struct {
+	ID      string `xml:"id,attr"`
+	For     string `xml:"for,attr"`
+	Name    string `xml:"attr.name,attr"`
+	Type    string `xml:"attr.type,attr"`
+	Default string `xml:"default,omitempty"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+// WriteGraphML записывает граф в GraphML; вершина i получает id "n<i>", дуги идут в том же порядке,
+// что и в текстовом формате, а имена и координаты вершин записываются данными вершин,
+// поэтому преобразование в обе стороны не теряет данных
+func WriteGraphML(w io.Writer, graph [][]trio, limit LimitType, level int, data VertexData) error {
+	m, closed := CountEdges(graph)
+	if limit == MixLimit {
+		level = closed
+	}
+	return writeGraphML(w, graph, GraphHeader{len(graph), m, level, limit, true}, data)
+}
+
+// writeGraphML переносит заголовок текстового формата как есть: тип ограничения - только если он объявлен
+func writeGraphML(w io.Writer, graph [][]trio, h GraphHeader, data VertexData) error {
+	doc := graphMLDocument{
+		XMLNS: graphMLNamespace,
+		Keys: []graphMLKey{
+			{ID: "level", For: "graph", Name: graphMLLevelName, Type: "int"},
+			{ID: "weight", For: "edge", Name: graphMLWeightName, Type: "int"},
+			{ID: "type", For: "edge", Name: graphMLEdgeTypeName, Type: "int", Default: "0"},
+		},
+		Graph: graphMLGraph{
+			ID:          "G",
+			EdgeDefault: "directed",
+			Data:        []graphMLData{{"level", strconv.Itoa(h.Level)}},
+			Nodes:       make([]graphMLNode, len(graph)),
+			Edges:       make([]graphMLEdge, 0, h.M),
+		},
+	}
+	if h.Declared {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "constraint", For: "graph", Name: graphMLConstraintName, Type: "string"})
+		doc.Graph.Data = append(doc.Graph.Data, graphMLData{"constraint", h.Kind.String()})
+	}
+	if data.Labels != nil {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "label", For: "node", Name: graphMLLabelName, Type: "string"})
+	}
+	if data.Coords != nil {
+		doc.Keys = append(doc.Keys,
+			graphMLKey{ID: "lon", For: "node", Name: graphMLLonName, Type: "double"},
+			graphMLKey{ID: "lat", For: "node", Name: graphMLLatName, Type: "double"})
+	}
+	for i := range graph {
+		node := &doc.Graph.Nodes[i]
+		node.ID = "n" + strconv.Itoa(i)
+		if data.Labels != nil && data.Labels[i] != "" {
+			node.Data = append(node.Data, graphMLData{"label", data.Labels[i]})
+		}
+		if c := data.Coords; c != nil && !math.IsNaN(c[i].X) && !math.IsNaN(c[i].Y) {
+			node.Data = append(node.Data,
+				graphMLData{"lon", strconv.FormatFloat(c[i].X, 'g', -1, 64)},
+				graphMLData{"lat", strconv.FormatFloat(c[i].Y, 'g', -1, 64)})
+		}
+	}
+	for i, v := range graph {
+		for _, e := range v {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: doc.Graph.Nodes[i].ID,
+				Target: doc.Graph.Nodes[e.EndPoint].ID,
+				Data:   []graphMLData{{"weight", strconv.Itoa(e.Weight)}, {"type", strconv.Itoa(int(e.EdgeType))}},
+			})
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// parseGraphMLInt принимает и целые числа, записанные редактором как double ("5.0")
+func parseGraphMLInt(s string) (int, error) {
+	if v, err := strconv.Atoi(s); err == nil {
+		return v, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return 0, fmt.Errorf("ожидалось целое число: %q", s)
+	}
+	return int(f), nil
+}
+
+// parseEdgeTypeName разбирает тип дуги, записанный числом или именем (Normal, Closed, Boosting, Barrier, Magnet)
+func parseEdgeTypeName(s string) (EdgeType, error) {
+	s = strings.TrimSpace(s)
+	for i, name := range edgeTypeNames {
+		if strings.EqualFold(s, name) {
+			return EdgeType(i), nil
+		}
+	}
+	t, err := strconv.Atoi(s)
+	if err != nil || t < int(Normal) || t > int(Magnet) {
+		return 0, fmt.Errorf("неизвестный тип дуги %q", s)
+	}
+	return EdgeType(t), nil
+}
+
+// ReadGraphML читает граф из GraphML; вершины нумеруются в порядке появления элементов node.
+// Заголовок заполняется так же, как при чтении текстового формата. Имя вершины берется из данных label,
+// а без них - из id вершины, если это допустимое имя, кроме id вида "n<номер вершины>", которые пишет WriteGraphML
+func ReadGraphML(r io.Reader) ([][]trio, GraphHeader, VertexData, error) {
+	graph, h, data, err := readGraphML(r)
+	if err != nil {
+		return nil, GraphHeader{}, VertexData{}, err
+	}
+	h.N = len(graph)
+	h.M, _ = CountEdges(graph)
+	return graph, h, data, nil
+}
+
+func readGraphML(r io.Reader) ([][]trio, GraphHeader, VertexData, error) {
+	var h GraphHeader
+	var data VertexData
+	r, err := Decompress(r)
+	if err != nil {
+		return nil, h, data, err
+	}
+	var doc graphMLDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, h, data, fmt.Errorf("GraphML: %v", err)
+	}
+	if doc.Graph.EdgeDefault == "undirected" {
+		return nil, h, data, fmt.Errorf("GraphML: неориентированные графы не поддерживаются")
+	}
+
+	//ключи по назначению: id ключа -> имя атрибута, и значения по умолчанию
+	names := make(map[string]string)
+	defaults := make(map[string]string)
+	for _, k := range doc.Keys {
+		names[k.ID] = k.Name
+		if k.Default != "" {
+			defaults[k.Name] = strings.TrimSpace(k.Default)
+		}
+	}
+	for _, d := range doc.Graph.Data {
+		switch names[d.Key] {
+		case graphMLLevelName:
+			if h.Level, err = parseGraphMLInt(strings.TrimSpace(d.Value)); err != nil {
+				return nil, h, data, fmt.Errorf("GraphML: уровень графа %q: %v", d.Value, err)
+			}
+		case graphMLConstraintName:
+			if h.Kind, err = ParseLimitType(strings.TrimSpace(d.Value)); err != nil {
+				return nil, h, data, fmt.Errorf("GraphML: %v", err)
+			}
+			h.Declared = true
+		}
+	}
+
+	index := make(map[string]int, len(doc.Graph.Nodes))
+	for i, node := range doc.Graph.Nodes {
+		if _, ok := index[node.ID]; ok {
+			return nil, h, data, fmt.Errorf("GraphML: повторная вершина %q", node.ID)
+		}
+		index[node.ID] = i
+	}
+	if data, err = readGraphMLVertexData(doc.Graph.Nodes, names); err != nil {
+		return nil, h, data, err
+	}
+	graph := make([][]trio, len(doc.Graph.Nodes))
+	for i, edge := range doc.Graph.Edges {
+		from, ok := index[edge.Source]
+		if !ok {
+			return nil, h, data, fmt.Errorf("GraphML: дуга %d: неизвестная вершина %q", i, edge.Source)
+		}
+		to, ok := index[edge.Target]
+		if !ok {
+			return nil, h, data, fmt.Errorf("GraphML: дуга %d: неизвестная вершина %q", i, edge.Target)
+		}
+		values := map[string]string{graphMLWeightName: defaults[graphMLWeightName], graphMLEdgeTypeName: defaults[graphMLEdgeTypeName]}
+		for _, d := range edge.Data {
+			if name := names[d.Key]; name == graphMLWeightName || name == graphMLEdgeTypeName {
+				values[name] = strings.TrimSpace(d.Value)
+			}
+		}
+		weight, err := parseGraphMLInt(values[graphMLWeightName])
+		if err != nil || weight < 0 {
+			return nil, h, data, fmt.Errorf("GraphML: дуга %d: недопустимый вес %q", i, values[graphMLWeightName])
+		}
+		edgeType := Normal
+		if values[graphMLEdgeTypeName] != "" {
+			if edgeType, err = parseEdgeTypeName(values[graphMLEdgeTypeName]); err != nil {
+				return nil, h, data, fmt.Errorf("GraphML: дуга %d: %v", i, err)
+			}
+		}
+		graph[from] = append(graph[from], trio{to, weight, edgeType})
+	}
+	return graph, h, data, nil
+}
+
+// readGraphMLVertexData собирает имена и координаты вершин; поля равны nil, если таких данных нет ни у одной вершины
+func readGraphMLVertexData(nodes []graphMLNode, names map[string]string) (VertexData, error) {
+	var data VertexData
+	labels := make([]string, len(nodes))
+	coords := make([]Coordinates, len(nodes))
+	named, located := false, false
+	seen := make(map[string]bool, len(nodes))
+	validLabel := func(name string) bool {
+		return name != "" && !strings.ContainsAny(name, " \t\r\n#") && !seen[name]
+	}
+	for i, node := range nodes {
+		coords[i] = Coordinates{math.NaN(), math.NaN()}
+		var lon, lat string
+		for _, d := range node.Data {
+			value := strings.TrimSpace(d.Value)
+			switch names[d.Key] {
+			case graphMLLabelName:
+				if !validLabel(value) {
+					return data, fmt.Errorf("GraphML: недопустимое или повторное имя вершины %q", value)
+				}
+				labels[i] = value
+			case graphMLLonName:
+				lon = value
+			case graphMLLatName:
+				lat = value
+			}
+		}
+		if labels[i] == "" && node.ID != "n"+strconv.Itoa(i) && validLabel(node.ID) {
+			labels[i] = node.ID
+		}
+		if labels[i] != "" {
+			seen[labels[i]] = true
+			named = true
+		}
+		if lon == "" && lat == "" {
+			continue
+		}
+		x, errLon := strconv.ParseFloat(lon, 64)
+		y, errLat := strconv.ParseFloat(lat, 64)
+		if errLon != nil || errLat != nil || !validLonLat(x, y) {
+			return data, fmt.Errorf("GraphML: недопустимые координаты вершины %q", node.ID)
+		}
+		coords[i] = Coordinates{x, y}
+		located = true
+	}
+	if named {
+		data.Labels = labels
+	}
+	if located {
+		data.Coords = coords
+	}
+	return data, nil
+}
+
+func GraphMLProgramm() {
+	var direction, filename, outFilename string
+
+	fmt.Print("Введите направление (export - текст в GraphML, import - GraphML в текст), входной и выходной файлы: ")
+	fmt.Fscan(os.Stdin, &direction, &filename, &outFilename)
+
+	//заголовок (уровень или число запрещенных дуг и тип ограничения, если он указан), имена и координаты вершин
+	//переносятся без изменений
+	var graph [][]trio
+	var header GraphHeader
+	var data VertexData
+	switch direction {
+	case "export":
+		g, h, err := LoadGraphFile(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graph, header, data = g.AdjacencyList(), h, VertexData{g.Labels(), g.Coordinates()}
+	case "import":
+		f, err := OpenInput(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graph, header, data, err = ReadGraphML(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("неизвестное направление: %q", direction)
+	}
+
+	out, err := os.Create(outFilename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+	if direction == "export" {
+		err = writeGraphML(out, graph, header, data)
+	} else {
+		err = writeGraphText(out, graph, header)
+		if err == nil && data.Labels != nil {
+			err = WriteLabels(out, data.Labels)
+		}
+		if err == nil && data.Coords != nil {
+			err = WriteCoordinates(out, data.Coords)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Граф записан в файл", outFilename)
+}
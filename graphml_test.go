@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGraphMLRoundTripsTextFormat(t *testing.T) {
+	rnd := rand.New(rand.NewSource(47))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 100; i++ {
+			adj, level := randomTestGraph(rnd, limit)
+			var text, graphML, again bytes.Buffer
+			WriteGraph(&text, adj, limit, level)
+			if i%2 == 1 {
+				//файл без типа ограничения в заголовке
+				legacy := strings.Replace(text.String(), " "+limit.String()+"\n", "\n", 1)
+				text.Reset()
+				text.WriteString(legacy)
+			}
+
+			graph, header, _, err := ParseGraph(bytes.NewReader(text.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writeGraphML(&graphML, graph, header, VertexData{}); err != nil {
+				t.Fatal(err)
+			}
+			imported, importedHeader, data, err := ReadGraphML(&graphML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if importedHeader != header || data.Labels != nil || data.Coords != nil {
+				t.Fatalf("%v: got header %+v, want %+v", limit, importedHeader, header)
+			}
+			writeGraphText(&again, imported, importedHeader)
+			if again.String() != text.String() {
+				t.Fatalf("%v: got\n%s\nwant\n%s", limit, again.String(), text.String())
+			}
+		}
+	}
+}
+
+func TestReadGraphMLFromEditor(t *testing.T) {
+	//ключи с произвольными id, тип дуги по имени, вес и тип по умолчанию
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key id="d0" for="graph" attr.name="level" attr.type="int"/>
+  <key id="d5" for="edge" attr.name="weight" attr.type="double"><default>7</default></key>
+  <key id="d6" for="edge" attr.name="EdgeType" attr.type="string"/>
+  <key id="d9" for="node" yfiles.type="nodegraphics"/>
+  <graph id="G" edgedefault="directed">
+    <data key="d0">2</data>
+    <node id="MSK"><data key="d9"><y:ShapeNode/></data></node>
+    <node id="SPB"/>
+    <edge id="e0" source="MSK" target="SPB"><data key="d5">5.0</data><data key="d6">boosting</data></edge>
+    <edge id="e1" source="SPB" target="MSK"><data key="d6">3</data></edge>
+    <edge id="e2" source="SPB" target="SPB"/>
+  </graph>
+</graphml>`
+	graph, header, data, err := ReadGraphML(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]trio{{{1, 5, Boosting}}, {{0, 7, Barrier}, {1, 7, Normal}}}
+	if header != (GraphHeader{N: 2, M: 3, Level: 2}) || !reflect.DeepEqual(graph, want) {
+		t.Fatalf("got %v %+v", graph, header)
+	}
+	//id вершин становятся их именами
+	if !reflect.DeepEqual(data.Labels, []string{"MSK", "SPB"}) || data.Coords != nil {
+		t.Fatalf("got vertex data %+v", data)
+	}
+}
+
+func TestReadGraphMLRejectsInvalidInput(t *testing.T) {
+	const keys = `<key id="w" for="edge" attr.name="weight"/><key id="t" for="edge" attr.name="EdgeType"/>`
+	for input, want := range map[string]string{
+		`<graphml><graph edgedefault="undirected"/></graphml>`:                                                                                        "неориентированные",
+		`<graphml>` + keys + `<graph><node id="a"/><edge source="a" target="b"><data key="w">1</data></edge></graph></graphml>`:                       "неизвестная вершина",
+		`<graphml>` + keys + `<graph><node id="a"/><edge source="a" target="a"/></graph></graphml>`:                                                   "недопустимый вес",
+		`<graphml>` + keys + `<graph><node id="a"/><edge source="a" target="a"><data key="w">-1</data></edge></graph></graphml>`:                      "недопустимый вес",
+		`<graphml>` + keys + `<graph><node id="a"/><edge source="a" target="a"><data key="w">1</data><data key="t">9</data></edge></graph></graphml>`: "неизвестный тип",
+		`<graphml><graph><node id="a"/><node id="a"/></graph></graphml>`:                                                                              "повторная вершина",
+		`<graphml><graph>`: "GraphML",
+		`<graphml><key id="l" for="node" attr.name="label"/><graph><node id="a"><data key="l">x y</data></node></graph></graphml>`: "имя вершины",
+		`<graphml><key id="x" for="node" attr.name="lon"/><graph><node id="a"><data key="x">200</data></node></graph></graphml>`:   "координаты",
+	} {
+		if _, _, _, err := ReadGraphML(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestGraphMLRoundTripsVertexData(t *testing.T) {
+	//имена есть не у всех вершин, координаты тоже, а вершина 3 названа так же, как id, который пишет WriteGraphML
+	text := labeledGraphText + "3 n3\ncoords\n0 37.6173 55.7558\n2 30.3351 59.9343\n"
+	g, header, err := LoadGraphFile(writeTempGraph(t, text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graphML, again bytes.Buffer
+	if err := writeGraphML(&graphML, g.AdjacencyList(), header, VertexData{g.Labels(), g.Coordinates()}); err != nil {
+		t.Fatal(err)
+	}
+	graph, importedHeader, data, err := ReadGraphML(&graphML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeGraphText(&again, graph, importedHeader)
+	WriteLabels(&again, data.Labels)
+	WriteCoordinates(&again, data.Coords)
+	imported, _, err := LoadGraphFile(writeTempGraph(t, again.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(imported.AdjacencyList(), g.AdjacencyList()) || !reflect.DeepEqual(imported.Labels(), g.Labels()) {
+		t.Fatalf("got %v %v, want %v %v", imported.AdjacencyList(), imported.Labels(), g.AdjacencyList(), g.Labels())
+	}
+	got, want := imported.Coordinates(), g.Coordinates()
+	for v := range want {
+		if got[v] != want[v] && !(math.IsNaN(got[v].X) && math.IsNaN(want[v].X)) {
+			t.Fatalf("vertex %d: got %v, want %v", v, got[v], want[v])
+		}
+	}
+}
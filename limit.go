@@ -0,0 +1,163 @@
+package main
+
+import "fmt"
+
+type LimitType int
+
+const (
+	MixLimit LimitType = iota
+	BarrierLimit
+	MagnetLimit
+	MagnetBarrierLimit
+)
+
+var limitNames = [...]string{"mix", "bar", "mag", "magbar"}
+
+func (t LimitType) String() string {
+	if t < 0 || int(t) >= len(limitNames) {
+		return fmt.Sprintf("LimitType(%d)", int(t))
+	}
+	return limitNames[t]
+}
+
+func ParseLimitType(s string) (LimitType, error) {
+	for i, name := range limitNames {
+		if s == name {
+			return LimitType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный тип ограничения: %q", s)
+}
+
+var edgeTypeNames = [...]string{"Normal", "Closed", "Boosting", "Barrier", "Magnet"}
+
+type Constraint struct {
+	Type  LimitType
+	Level int
+}
+
+// Validate проверяет уровень: он неотрицателен, а для mag не меньше 1, так как магнитная дуга
+// на верхнем уровне опускает путь на уровень ниже; у mix уровня нет
+func (c Constraint) Validate() error {
+	switch c.Type {
+	case MixLimit:
+		return nil
+	case BarrierLimit, MagnetBarrierLimit:
+		if c.Level < 0 {
+			return fmt.Errorf("уровень ограничения %v не может быть отрицательным: %d", c.Type, c.Level)
+		}
+	case MagnetLimit:
+		if c.Level < 1 {
+			return fmt.Errorf("уровень ограничения %v должен быть не меньше 1: %d", c.Type, c.Level)
+		}
+	default:
+		return fmt.Errorf("неизвестный тип ограничения: %v", c.Type)
+	}
+	return nil
+}
+
+// Levels возвращает число уровней вспомогательного графа для ограничения
+func (c Constraint) Levels() int {
+	if c.Type == MixLimit {
+		return 2
+	}
+	return c.Level + 1
+}
+
+// LevelSpec задает уровень в начале пути и допустимые уровни в финише;
+// AcceptLevel == nil допускает любой уровень
+type LevelSpec struct {
+	StartLevel  int
+	AcceptLevel func(level int) bool
+}
+
+func (s LevelSpec) Accepts(level int) bool {
+	return s.AcceptLevel == nil || s.AcceptLevel(level)
+}
+
+// AtLeastLevel допускает финиш не ниже уровня min, например "прибыть, сделав не меньше min ускорений"
+func AtLeastLevel(min int) func(int) bool {
+	return func(level int) bool { return level >= min }
+}
+
+func LevelSet(levels ...int) func(int) bool {
+	return func(level int) bool { return Contains(levels, level) }
+}
+
+// MixEdges сообщает, что в графе только обычные и закрытые дуги: для графа с другими дугами
+// ограничение mix не определено и вспомогательный граф для него не строится
+func MixEdges(graph [][]trio) bool {
+	for _, v := range graph {
+		for _, e := range v {
+			if e.EdgeType != Normal && e.EdgeType != Closed {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ContainsMagnetEdges(edges []trio) bool {
+	for _, e := range edges {
+		if e.EdgeType == Magnet {
+			return true
+		}
+	}
+	return false
+}
+
+// NextLevel возвращает уровень после прохода дуги типа edgeType с уровня level;
+// magnetVertex означает, что из начальной вершины дуги выходят магнитные дуги
+func NextLevel(c Constraint, edgeType EdgeType, level int, magnetVertex bool) (int, bool) {
+	if level < 0 || level >= c.Levels() {
+		return 0, false
+	}
+	switch c.Type {
+	case MixLimit:
+		switch edgeType {
+		case Normal:
+			return 0, true
+		case Closed:
+			return 1, level == 0
+		}
+	case BarrierLimit:
+		switch edgeType {
+		case Normal:
+			return level, true
+		case Boosting:
+			if level < c.Level {
+				return level + 1, true
+			}
+			return level, true
+		case Barrier:
+			return 0, level == c.Level
+		}
+	case MagnetLimit:
+		if level == c.Level {
+			if magnetVertex {
+				return level - 1, edgeType == Magnet && level > 0
+			}
+			return level, true
+		}
+		switch edgeType {
+		case Normal, Magnet:
+			return level, true
+		case Boosting:
+			return level + 1, true
+		}
+	case MagnetBarrierLimit:
+		if level == c.Level {
+			if magnetVertex {
+				return level, edgeType == Magnet
+			}
+			return level, true
+		}
+		switch edgeType {
+		case Normal:
+			return level, true
+		case Boosting:
+			return level + 1, true
+		}
+	}
+	return 0, false
+}
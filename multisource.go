@@ -0,0 +1,83 @@
+package main
+
+import "fmt"
+
+// Source - стартовая вершина на уровне Level с начальным расстоянием Offset (например, временем выезда со склада)
+type Source struct {
+	Vertex int
+	Level  int
+	Offset int
+}
+
+type MultiRoute struct {
+	Start  int
+	Finish int
+	Path   []int
+	Dist   int
+}
+
+// DeijkstraVectorAlgorithmFromLevel запускает векторный алгоритм для ограничения c
+// из вершины startPoint, уже находящейся на уровне startLevel
+func DeijkstraVectorAlgorithmFromLevel(graph [][]trio, c Constraint, startPoint int, startLevel int, finishPoint int) ([]int, int) {
+	return DeijkstraVectorAlgorithmWithLevels(graph, c, startPoint, finishPoint, LevelSpec{startLevel, nil})
+}
+
+// DeijkstraVectorAlgorithmWithLevels ищет путь из (startPoint, spec.StartLevel) до финиша на уровне,
+// допускаемом spec; путь может проходить через финиш на недопустимых уровнях
+func DeijkstraVectorAlgorithmWithLevels(graph [][]trio, c Constraint, startPoint int, finishPoint int, spec LevelSpec) ([]int, int) {
+	return DeijkstraVectorAlgorithmOnGraph(NewGraph(graph), c, startPoint, finishPoint, spec)
+}
+
+func DeijkstraVectorAlgorithmOnGraph(g *Graph, c Constraint, startPoint int, finishPoint int, spec LevelSpec) ([]int, int) {
+	route := multiSourceVectorAlgorithm(g, c, []Source{{startPoint, spec.StartLevel, 0}}, []int{finishPoint}, spec.AcceptLevel)
+	if route.Path == nil {
+		return []int{startPoint}, route.Dist
+	}
+	return route.Path, route.Dist
+}
+
+// RouteOnGraph ищет путь из startPoint в finishPoint для любого ограничения: векторным алгоритмом,
+// а для mix - поиском по состояниям (вершина, число пройденных закрытых дуг) на рабочих массивах
+// без построения вспомогательного графа
+func RouteOnGraph(g *Graph, c Constraint, startPoint int, finishPoint int) ([]int, int) {
+	if c.Type != MixLimit {
+		return DeijkstraVectorAlgorithmOnGraph(g, c, startPoint, finishPoint, LevelSpec{})
+	}
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(g.N(), c.Levels(), []Source{{startPoint, 0, 0}}, nil)
+	path := w.shortestStatePath(g, c, finishPoint, nil)
+	if path == nil {
+		return []int{startPoint}, int(^uint(0) >> 1)
+	}
+	return path.vertices(), path.dist
+}
+
+// MultiSourceVectorAlgorithm ищет кратчайший путь от любой из стартовых вершин (с учетом смещений)
+// до любой из финишных; Start и Finish равны -1, если ни одна пара не соединена
+func MultiSourceVectorAlgorithm(graph [][]trio, c Constraint, sources []Source, finishPoints []int) MultiRoute {
+	return multiSourceVectorAlgorithm(NewGraph(graph), c, sources, finishPoints, nil)
+}
+
+func MultiSourceVectorAlgorithmOnGraph(g *Graph, c Constraint, sources []Source, finishPoints []int) MultiRoute {
+	return multiSourceVectorAlgorithm(g, c, sources, finishPoints, nil)
+}
+
+func multiSourceVectorAlgorithm(graph *Graph, c Constraint, sources []Source, finishPoints []int, acceptLevel func(int) bool) MultiRoute {
+	var path []int
+	var dist int
+	switch c.Type {
+	case BarrierLimit:
+		path, dist, _ = deijkstraVectorAlgorithmForBarrier(graph, sources, finishPoints, acceptLevel, c.Level, false)
+	case MagnetLimit:
+		path, dist, _ = deijkstraVectorAlgorithmForMagnet(graph, sources, finishPoints, acceptLevel, c.Level, false)
+	case MagnetBarrierLimit:
+		path, dist, _ = deijkstraVectorAlgorithmForMagnetBarrier(graph, sources, finishPoints, acceptLevel, c.Level, false)
+	default:
+		panic(fmt.Sprintf("%v", c.Type))
+	}
+	if path == nil {
+		return MultiRoute{-1, -1, nil, dist}
+	}
+	return MultiRoute{path[0], path[len(path)-1], path, dist}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMultiSourceMatchesOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(11))
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			n := len(graph)
+			sources := make([]Source, 1+rnd.Intn(3))
+			for j := range sources {
+				sources[j] = Source{rnd.Intn(n), 0, rnd.Intn(10)}
+			}
+			finishes := make([]int, 1+rnd.Intn(3))
+			for j := range finishes {
+				finishes[j] = rnd.Intn(n)
+			}
+
+			want := inf
+			offsets := make(map[int]int)
+			for _, s := range sources {
+				if o, ok := offsets[s.Vertex]; !ok || s.Offset < o {
+					offsets[s.Vertex] = s.Offset
+				}
+				for _, f := range finishes {
+					if d := oracleDistance(graph, limit, level, s.Vertex, f); d != inf && s.Offset+d < want {
+						want = s.Offset + d
+					}
+				}
+			}
+
+			route := MultiSourceVectorAlgorithm(graph, Constraint{limit, level}, sources, finishes)
+			if route.Dist != want {
+				t.Fatalf("%v level %d sources %v finishes %v: got %d, want %d\n%v", limit, level, sources, finishes, route.Dist, want, graph)
+			}
+			if want == inf {
+				if route.Start != -1 || route.Finish != -1 {
+					t.Fatalf("unreachable route reports pair %d->%d", route.Start, route.Finish)
+				}
+				continue
+			}
+			if !Contains(finishes, route.Finish) || route.Path[0] != route.Start || route.Path[len(route.Path)-1] != route.Finish {
+				t.Fatalf("route %+v does not match finishes %v", route, finishes)
+			}
+			offset, ok := offsets[route.Start]
+			if !ok || offset+minRealisedWeight(graph, limit, level, route.Path) != route.Dist {
+				t.Fatalf("%v level %d: route %+v is not realised from sources %v\n%v", limit, level, route, sources, graph)
+			}
+		}
+	}
+}
+
+func TestRouteOnGraphMixMatchesAuxGraph(t *testing.T) {
+	rnd := rand.New(rand.NewSource(50))
+	for i := 0; i < 500; i++ {
+		adj, level := randomTestGraph(rnd, MixLimit)
+		start, finish := rnd.Intn(len(adj)), rnd.Intn(len(adj))
+		path, dist := RouteOnGraph(NewGraph(adj), Constraint{MixLimit, level}, start, finish)
+		if want := auxDistance(adj, MixLimit, level, start, finish); dist != want {
+			t.Fatalf("%v %d -> %d: got %d, want %d", adj, start, finish, dist, want)
+		}
+		if dist == inf {
+			continue
+		}
+		if path[0] != start || path[len(path)-1] != finish {
+			t.Fatalf("%v %d -> %d: got path %v", adj, start, finish, path)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WithLabels возвращает граф с теми же дугами и именами вершин labels (например, кодами станций);
+// пустое имя означает, что вершина обозначается своим номером
+func (g *Graph) WithLabels(labels []string) (*Graph, error) {
+	if len(labels) != g.N() {
+		return nil, fmt.Errorf("имен вершин %d, а вершин %d", len(labels), g.N())
+	}
+	index := make(map[string]int, len(labels))
+	for v, name := range labels {
+		if name == "" {
+			continue
+		}
+		if strings.ContainsAny(name, " \t\r\n#") {
+			return nil, fmt.Errorf("недопустимое имя вершины %d: %q", v, name)
+		}
+		if u, ok := index[name]; ok {
+			return nil, fmt.Errorf("имя %q у вершин %d и %d", name, u, v)
+		}
+		index[name] = v
+	}
+	labeled := *g
+	labeled.labels, labeled.index = labels, index
+	return &labeled, nil
+}
+
+// Labels возвращает имена вершин или nil, если граф без имен
+func (g *Graph) Labels() []string {
+	return g.labels
+}
+
+// Label возвращает имя вершины v, а для вершины без имени - ее номер
+func (g *Graph) Label(v int) string {
+	if g.labels != nil && g.labels[v] != "" {
+		return g.labels[v]
+	}
+	return strconv.Itoa(v)
+}
+
+// VertexIndex находит вершину по имени; если такого имени нет, id разбирается как номер вершины
+func (g *Graph) VertexIndex(id string) (int, error) {
+	if v, ok := g.index[id]; ok {
+		return v, nil
+	}
+	if v, err := strconv.Atoi(id); err == nil && v >= 0 && v < g.N() {
+		return v, nil
+	}
+	return -1, fmt.Errorf("неизвестная вершина %q", id)
+}
+
+func (g *Graph) PathLabels(path []int) []string {
+	names := make([]string, len(path))
+	for i, v := range path {
+		names[i] = g.Label(v)
+	}
+	return names
+}
+
+// WriteLabels дописывает к графу в текстовом формате раздел labels с непустыми именами вершин
+func WriteLabels(w io.Writer, labels []string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "labels")
+	for v, name := range labels {
+		if name != "" {
+			fmt.Fprintln(bw, v, name)
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadGraphFile читает граф в текстовом формате вместе с необязательными разделами имен и координат вершин
+func LoadGraphFile(filename string) (*Graph, GraphHeader, error) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		return nil, GraphHeader{}, err
+	}
+	defer f.Close()
+	return loadGraph(NewGraphParser(f))
+}
+
+// loadGraph читает граф и разделы после дуг; статистика чтения остается в p
+func loadGraph(p *GraphParser) (*Graph, GraphHeader, error) {
+	adj, h, err := readGraph(p, ReaderOptions{})
+	if err != nil {
+		return nil, h, err
+	}
+	data, err := p.ReadVertexData(len(adj))
+	if err != nil {
+		return nil, h, err
+	}
+	g := NewGraph(adj)
+	if data.Labels != nil {
+		if g, err = g.WithLabels(data.Labels); err != nil {
+			return nil, h, err
+		}
+	}
+	if data.Coords != nil {
+		if g, err = g.WithCoordinates(data.Coords); err != nil {
+			return nil, h, err
+		}
+	}
+	return g, h, nil
+}
+
+// RouteByName ищет путь между вершинами, заданными именами или номерами, и возвращает имена вершин пути;
+// для недостижимого финиша путь пустой, а расстояние равно бесконечности
+func RouteByName(g *Graph, c Constraint, start string, finish string) ([]string, int, error) {
+	startPoint, err := g.VertexIndex(start)
+	if err != nil {
+		return nil, 0, err
+	}
+	finishPoint, err := g.VertexIndex(finish)
+	if err != nil {
+		return nil, 0, err
+	}
+	path, dist := RouteOnGraph(g, c, startPoint, finishPoint)
+	if dist == int(^uint(0)>>1) {
+		return nil, dist, nil
+	}
+	return g.PathLabels(path), dist, nil
+}
+
+func RouteProgramm() {
+	var filename, start, finish string
+
+	fmt.Print("Введите имя файла с графом: ")
+	fmt.Fscan(os.Stdin, &filename)
+	g, h, err := LoadGraphFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	//тип ограничения берется из заголовка файла, а если его там нет - спрашивается
+	header := promptLimitUnlessDeclared(h)
+	fmt.Print("Введите начальную и конечную вершины (имена или номера): ")
+	fmt.Fscan(os.Stdin, &start, &finish)
+
+	c := header.Constraint()
+	if c.Type == MixLimit && !MixEdges(g.AdjacencyList()) {
+		log.Fatalf("в графе есть дуги, недопустимые для ограничения %v", c.Type)
+	}
+	startPoint, err := g.VertexIndex(start)
+	if err != nil {
+		log.Fatal(err)
+	}
+	finishPoint, err := g.VertexIndex(finish)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path, dist := RouteOnGraph(g, c, startPoint, finishPoint)
+	if dist == int(^uint(0)>>1) {
+		fmt.Println("Пути не существует")
+		return
+	}
+	fmt.Println("Путь:", strings.Join(g.PathLabels(path), " -> "), ", его длина:", dist)
+
+	if g.Coordinates() == nil {
+		return
+	}
+	var geoFilename string
+	fmt.Print("Введите имя файла GeoJSON для маршрута (- без экспорта): ")
+	fmt.Fscan(os.Stdin, &geoFilename)
+	if geoFilename == "-" {
+		return
+	}
+	if err := WriteRouteGeoJSONFile(geoFilename, g, c, path); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Маршрут записан в файл", geoFilename)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const labeledGraphText = `4 4 1
+0 1 5 0
+1 2 3 2
+0 2 1 3
+2 3 2 0
+labels
+0 MSK
+1 TVR
+# у вершины 3 имени нет
+2 SPB
+`
+
+func writeTempGraph(t *testing.T, text string) string {
+	filename := filepath.Join(t.TempDir(), "graph.txt")
+	if err := os.WriteFile(filename, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadGraphFile(t *testing.T) {
+	filename := writeTempGraph(t, labeledGraphText)
+	g, header, err := LoadGraphFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header != (GraphHeader{N: 4, M: 4, Level: 1}) || !reflect.DeepEqual(g.Labels(), []string{"MSK", "TVR", "SPB", ""}) {
+		t.Fatalf("got %v %q", header, g.Labels())
+	}
+	for id, want := range map[string]int{"MSK": 0, "SPB": 2, "3": 3, "1": 1} {
+		if v, err := g.VertexIndex(id); err != nil || v != want {
+			t.Errorf("%s: got %d, %v, want %d", id, v, err, want)
+		}
+	}
+	for _, id := range []string{"KZN", "4", "-1"} {
+		if _, err := g.VertexIndex(id); err == nil {
+			t.Errorf("%s: no error", id)
+		}
+	}
+
+	//прежние читатели не видят раздела имен
+	if adj, _ := ReadGraphForBarrierSpeedTest(filename); !reflect.DeepEqual(NewGraph(adj), NewGraph(g.AdjacencyList())) {
+		t.Fatalf("legacy reader: got %v", adj)
+	}
+
+	var buf bytes.Buffer
+	WriteGraph(&buf, g.AdjacencyList(), BarrierLimit, header.Level)
+	WriteLabels(&buf, g.Labels())
+	again, _, err := LoadGraphFile(writeTempGraph(t, buf.String()))
+	if err != nil || !reflect.DeepEqual(again, g) {
+		t.Fatalf("round trip: %v", err)
+	}
+}
+
+func TestRouteByName(t *testing.T) {
+	g, _, err := LoadGraphFile(writeTempGraph(t, labeledGraphText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, dist, err := RouteByName(g, Constraint{BarrierLimit, 1}, "MSK", "3")
+	if err != nil || dist != 10 || !reflect.DeepEqual(path, []string{"MSK", "TVR", "SPB", "3"}) {
+		t.Fatalf("got %v %d %v", path, dist, err)
+	}
+	//две запрещенные дуги подряд брать нельзя, поэтому путь идет по прямой дуге
+	mix, _ := NewGraph([][]trio{{{1, 2, Closed}, {2, 9, Normal}}, {{2, 2, Closed}}, nil}).WithLabels([]string{"A", "B", "C"})
+	if path, dist, err := RouteByName(mix, Constraint{MixLimit, 0}, "A", "C"); err != nil || dist != 9 || !reflect.DeepEqual(path, []string{"A", "C"}) {
+		t.Fatalf("mix: got %v %d %v", path, dist, err)
+	}
+	if path, dist, err := RouteByName(g, Constraint{BarrierLimit, 1}, "SPB", "MSK"); err != nil || path != nil || dist != inf {
+		t.Fatalf("unreachable: got %v %d %v", path, dist, err)
+	}
+	if _, _, err := RouteByName(g, Constraint{BarrierLimit, 1}, "KZN", "MSK"); err == nil {
+		t.Fatal("unknown start: no error")
+	}
+}
+
+func TestReadLabelsRejectsInvalidTable(t *testing.T) {
+	for text, want := range map[string]string{
+		"2 0 0\nnames\n":            "неизвестный раздел",
+		"2 0 0\nlabels\n2 A\n":      "недопустимый номер вершины",
+		"2 0 0\nlabels\n0 A\n1 A\n": "повторное имя",
+		"2 0 0\nlabels\n0 A\n0 B\n": "повторное имя",
+		"2 0 0\nlabels\n0 A B\n":    "ожидались номер и имя",
+	} {
+		if _, _, err := LoadGraphFile(writeTempGraph(t, text)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got error %v, want %q", text, err, want)
+		}
+	}
+	if _, err := NewGraph(make([][]trio, 2)).WithLabels([]string{"A B", ""}); err == nil {
+		t.Error("label with a space: no error")
+	}
+}
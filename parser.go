@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Текстовый формат графа:
+//
+//	n m k [kind]              - заголовок: k - уровень ограничения или число запрещенных дуг для mix,
+//	                            kind - необязательный тип ограничения (mix, bar, mag, magbar)
+//	from to weight type       - m строк с дугами
+//	labels                    - необязательный раздел с именами вершин после дуг
+//	index name                - имя вершины index (одно слово без пробелов и '#')
+//	coords                    - необязательный раздел с координатами вершин
+//	index lon lat             - долгота и широта вершины index в градусах
+//
+// Пустые строки и комментарии от символа '#' до конца строки пропускаются.
+const maxGraphLineLength = 1 << 20
+
+// maxGraphVertices ограничивает число вершин из заголовка: список смежности выделяется сразу на n вершин,
+// и без ограничения одна строка заголовка может исчерпать память
+const maxGraphVertices = 1 << 24
+
+// GraphHeader - заголовок "n m k [kind]" текстового формата. Level - третье число заголовка: уровень
+// ограничения, а для mix - число запрещенных дуг. Declared сообщает, что тип ограничения Kind указан в файле
+type GraphHeader struct {
+	N        int
+	M        int
+	Level    int
+	Kind     LimitType
+	Declared bool
+}
+
+// ParseStats - объем разобранных данных и время разбора
+type ParseStats struct {
+	Bytes    int64
+	Lines    int
+	Edges    int
+	Skipped  int
+	Duration time.Duration
+}
+
+func (s ParseStats) EdgesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Edges) / s.Duration.Seconds()
+}
+
+func (s ParseStats) MegabytesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / (1 << 20) / s.Duration.Seconds()
+}
+
+func (s ParseStats) String() string {
+	return fmt.Sprintf("прочитано дуг - %d (пропущено - %d), строк - %d, байт - %d за %v: %.0f дуг/с, %.1f МБ/с",
+		s.Edges, s.Skipped, s.Lines, s.Bytes, s.Duration, s.EdgesPerSecond(), s.MegabytesPerSecond())
+}
+
+// countingReader считает байты, прочитанные из источника
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// GraphParser читает текстовый формат построчно через буфер, не выделяя память на каждую строку
+type GraphParser struct {
+	source  *countingReader
+	scanner *bufio.Scanner
+	fields  [5][]byte
+	stats   ParseStats
+	started time.Time
+}
+
+func NewGraphParser(r io.Reader) *GraphParser {
+	source := &countingReader{r: r}
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), maxGraphLineLength)
+	return &GraphParser{source: source, scanner: scanner, started: time.Now()}
+}
+
+// nextLine возвращает поля следующей непустой строки без комментария; полей может быть больше len(p.fields),
+// тогда возвращаются первые len(p.fields) из них и count больше длины результата
+func (p *GraphParser) nextLine() (fields [][]byte, count int, ok bool) {
+	for p.scanner.Scan() {
+		p.stats.Lines++
+		line := p.scanner.Bytes()
+		if i := bytes.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		count = 0
+		for len(line) > 0 {
+			start := 0
+			for start < len(line) && isGraphSpace(line[start]) {
+				start++
+			}
+			end := start
+			for end < len(line) && !isGraphSpace(line[end]) {
+				end++
+			}
+			if start < end {
+				if count < len(p.fields) {
+					p.fields[count] = line[start:end]
+				}
+				count++
+			}
+			line = line[end:]
+		}
+		if count > 0 {
+			if count > len(p.fields) {
+				return p.fields[:], count, true
+			}
+			return p.fields[:count], count, true
+		}
+	}
+	return nil, 0, false
+}
+
+func isGraphSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// parseGraphInt разбирает целое число так же, как fmt.Fscan (с префиксами 0x, 0o, 0b и 0 для восьмеричных),
+// но для обычных десятичных чисел обходится без преобразования в строку
+func parseGraphInt(token []byte) (int, error) {
+	if len(token) > 0 && len(token) <= 18 && (token[0] != '0' || len(token) == 1) {
+		v := 0
+		for _, c := range token {
+			if c < '0' || c > '9' {
+				return parseGraphIntSlow(token)
+			}
+			v = v*10 + int(c-'0')
+		}
+		return v, nil
+	}
+	return parseGraphIntSlow(token)
+}
+
+func parseGraphIntSlow(token []byte) (int, error) {
+	v, err := strconv.ParseInt(string(token), 0, strconv.IntSize)
+	return int(v), err
+}
+
+// ReadHeader читает заголовок "n m k [kind]"; при ошибке уже разобранные числа сохраняются,
+// недостающие остаются нулями, как при чтении через fmt.Fscanln
+func (p *GraphParser) ReadHeader() (GraphHeader, error) {
+	var h GraphHeader
+	fields, count, ok := p.nextLine()
+	if !ok {
+		if err := p.Err(); err != nil {
+			return h, err
+		}
+		return h, io.ErrUnexpectedEOF
+	}
+	values := []*int{&h.N, &h.M, &h.Level}
+	for i, field := range fields {
+		if i == len(values) {
+			break
+		}
+		v, err := parseGraphInt(field)
+		if err != nil {
+			return h, fmt.Errorf("строка %d: заголовок: %v", p.stats.Lines, err)
+		}
+		*values[i] = v
+	}
+	if count < len(values) || count > len(values)+1 {
+		return h, fmt.Errorf("строка %d: в заголовке %d значений вместо %d или %d", p.stats.Lines, count, len(values), len(values)+1)
+	}
+	if count == len(values)+1 {
+		kind, err := ParseLimitType(string(fields[len(values)]))
+		if err != nil {
+			return h, fmt.Errorf("строка %d: заголовок: %v", p.stats.Lines, err)
+		}
+		h.Kind, h.Declared = kind, true
+		if err := h.Validate(); err != nil {
+			return h, fmt.Errorf("строка %d: заголовок: %v", p.stats.Lines, err)
+		}
+	}
+	return h, nil
+}
+
+// readEdge разбирает строку дуги "from to weight type"; ok=false, если строк больше нет
+func (p *GraphParser) readEdge() (from int, edge trio, ok bool, err error) {
+	fields, count, ok := p.nextLine()
+	if !ok {
+		return 0, edge, false, p.Err()
+	}
+	if count != 4 {
+		return 0, edge, true, fmt.Errorf("строка %d: в описании дуги %d чисел вместо 4", p.stats.Lines, count)
+	}
+	var values [4]int
+	for i, field := range fields {
+		if values[i], err = parseGraphInt(field); err != nil {
+			return 0, edge, true, fmt.Errorf("строка %d: %v", p.stats.Lines, err)
+		}
+	}
+	return values[0], trio{values[1], values[2], EdgeType(values[3])}, true, nil
+}
+
+// ReadEdges читает до m дуг графа из n вершин с теми же правилами, что и функция ReadEdges
+func (p *GraphParser) ReadEdges(n int, m int, printEdges bool) ([][]trio, error) {
+	if n < 0 {
+		n = 0
+	}
+	if n > maxGraphVertices {
+		return nil, fmt.Errorf("число вершин %d больше допустимого %d", n, maxGraphVertices)
+	}
+	graph := make([][]trio, n)
+	for i := 0; i < m; i++ {
+		vertex, edge, ok, err := p.readEdge()
+		if !ok || err != nil {
+			return graph, err
+		}
+		if vertex < 0 || vertex >= n || edge.EndPoint < 0 || edge.EndPoint >= n ||
+			edge.Weight < 0 || edge.EdgeType < Normal || edge.EdgeType > Magnet {
+			p.stats.Skipped++
+			continue
+		}
+		if printEdges {
+			fmt.Println(edge)
+		}
+		graph[vertex] = append(graph[vertex], edge)
+		p.stats.Edges++
+	}
+	return graph, nil
+}
+
+// VertexData - необязательные разделы после дуг; поля равны nil, если раздела нет в файле
+type VertexData struct {
+	Labels []string
+	Coords []Coordinates
+}
+
+// ReadVertexData читает разделы labels и coords после дуг в любом порядке.
+// Вершины, не упомянутые в разделе, остаются без имени (пустая строка) и без координат (NaN)
+func (p *GraphParser) ReadVertexData(n int) (VertexData, error) {
+	var data VertexData
+	var section string
+	seen := make(map[string]bool)
+	for {
+		fields, count, ok := p.nextLine()
+		if !ok {
+			return data, p.Err()
+		}
+		if count == 1 {
+			switch section = string(fields[0]); section {
+			case "labels":
+				if data.Labels != nil {
+					return data, fmt.Errorf("строка %d: повторный раздел labels", p.stats.Lines)
+				}
+				data.Labels = make([]string, n)
+			case "coords":
+				if data.Coords != nil {
+					return data, fmt.Errorf("строка %d: повторный раздел coords", p.stats.Lines)
+				}
+				data.Coords = make([]Coordinates, n)
+				for v := range data.Coords {
+					data.Coords[v] = Coordinates{math.NaN(), math.NaN()}
+				}
+			default:
+				return data, fmt.Errorf("строка %d: неизвестный раздел %q", p.stats.Lines, section)
+			}
+			continue
+		}
+		if section == "" {
+			return data, fmt.Errorf("строка %d: ожидался раздел labels или coords", p.stats.Lines)
+		}
+		v, err := parseGraphInt(fields[0])
+		if err != nil || v < 0 || v >= n {
+			return data, fmt.Errorf("строка %d: недопустимый номер вершины %q", p.stats.Lines, fields[0])
+		}
+		switch section {
+		case "labels":
+			if count != 2 {
+				return data, fmt.Errorf("строка %d: ожидались номер и имя вершины", p.stats.Lines)
+			}
+			name := string(fields[1])
+			if data.Labels[v] != "" || seen[name] {
+				return data, fmt.Errorf("строка %d: повторное имя вершины %d или имя %q", p.stats.Lines, v, name)
+			}
+			data.Labels[v] = name
+			seen[name] = true
+		case "coords":
+			if count != 3 {
+				return data, fmt.Errorf("строка %d: ожидались номер вершины, долгота и широта", p.stats.Lines)
+			}
+			lon, errLon := strconv.ParseFloat(string(fields[1]), 64)
+			lat, errLat := strconv.ParseFloat(string(fields[2]), 64)
+			if errLon != nil || errLat != nil || !validLonLat(lon, lat) {
+				return data, fmt.Errorf("строка %d: недопустимые координаты вершины %d", p.stats.Lines, v)
+			}
+			data.Coords[v] = Coordinates{lon, lat}
+		}
+	}
+}
+
+func validLonLat(lon float64, lat float64) bool {
+	return lon >= -180 && lon <= 180 && lat >= -90 && lat <= 90
+}
+
+// Err возвращает ошибку чтения источника или слишком длинной строки
+func (p *GraphParser) Err() error {
+	if err := p.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("строка %d длиннее %d байт", p.stats.Lines+1, maxGraphLineLength)
+		}
+		return err
+	}
+	return nil
+}
+
+// Stats возвращает статистику разбора на текущий момент
+func (p *GraphParser) Stats() ParseStats {
+	s := p.stats
+	s.Bytes = p.source.n
+	s.Duration = time.Since(p.started)
+	return s
+}
+
+// ParseGraph разбирает граф в текстовом формате из любого источника: файла, os.Stdin, распакованного потока или памяти
+func ParseGraph(r io.Reader) ([][]trio, GraphHeader, ParseStats, error) {
+	p := NewGraphParser(r)
+	graph, h, err := readGraph(p, ReaderOptions{})
+	return graph, h, p.Stats(), err
+}
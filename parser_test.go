@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fscanlnGraph - прежнее чтение через fmt.Fscanln, с которым парсер должен совпадать на существующих файлах
+func fscanlnGraph(r io.Reader) ([][]trio, GraphHeader) {
+	var h GraphHeader
+	fmt.Fscanln(r, &h.N, &h.M, &h.Level)
+	graph := make([][]trio, h.N)
+	for i := 0; i < h.M; i++ {
+		var vertex int
+		var edge trio
+		if _, err := fmt.Fscanln(r, &vertex, &edge.EndPoint, &edge.Weight, &edge.EdgeType); err != nil {
+			break
+		}
+		if vertex < 0 || vertex >= h.N || edge.EndPoint < 0 || edge.EndPoint >= h.N ||
+			edge.Weight < 0 || edge.EdgeType < Normal || edge.EdgeType > Magnet {
+			continue
+		}
+		graph[vertex] = append(graph[vertex], edge)
+	}
+	return graph, h
+}
+
+func TestParseGraphMatchesFscanln(t *testing.T) {
+	rnd := rand.New(rand.NewSource(43))
+	inputs := []string{
+		"3 3 1\n0 1 5 0\n1 2 3 2\n0 2 1 3\n",
+		"3 3 1\r\n0 1 5 0\r\n1 2 3 2\r\n0 2 1 3\r\n",
+		"2 5 1\n0 7 1 0\n-1 1 1 0\n0 1 -4 0\n0 1 1 9\n1 0 2 2\n",
+		"2 3 0\n0 1 010 0\n0 1 0x1f 1\n1 0 +4 0\n",
+		"2 3 1\n0 1 1 0\n0 1 x 0\n1 0 1 0\n",
+		"2 3 1\n0 1 1 0\n0 1 1 0 5\n1 0 1 0\n",
+		"2 3\n0 1 1 0\n",
+		"4 10 2\n0 1 1 0\n",
+		"",
+	}
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		adj, level := randomTestGraph(rnd, limit)
+		var buf bytes.Buffer
+		if err := WriteGraph(&buf, adj, limit, level); err != nil {
+			t.Fatal(err)
+		}
+		//в существующих файлах типа ограничения в заголовке нет
+		inputs = append(inputs, strings.Replace(buf.String(), " "+limit.String()+"\n", "\n", 1))
+	}
+	for _, input := range inputs {
+		wantGraph, wantHeader := fscanlnGraph(strings.NewReader(input))
+		p := NewGraphParser(strings.NewReader(input))
+		header, _ := p.ReadHeader()
+		graph, _ := p.ReadEdges(header.N, header.M, false)
+		if header != wantHeader || !reflect.DeepEqual(graph, wantGraph) {
+			t.Errorf("%q: got %v %v, want %v %v", input, header, graph, wantHeader, wantGraph)
+		}
+	}
+}
+
+func TestParseGraphSkipsCommentsAndBlankLines(t *testing.T) {
+	input := "# граф для теста\n\n3 3 1 # n m k\n\n0 1 5 0\n   \n# дуга 1 -> 2\n1 2 3 2\n\t0 2 1 3\t# барьер\n"
+	graph, header, stats, err := ParseGraph(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]trio{{{1, 5, Normal}, {2, 1, Barrier}}, {{2, 3, Boosting}}, nil}
+	if header != (GraphHeader{N: 3, M: 3, Level: 1}) || !reflect.DeepEqual(graph, want) {
+		t.Fatalf("got %v %v", header, graph)
+	}
+	if stats.Edges != 3 || stats.Skipped != 0 || stats.Lines != 9 || stats.Bytes != int64(len(input)) {
+		t.Fatalf("stats: %+v", stats)
+	}
+}
+
+func TestParseGraphReportsErrors(t *testing.T) {
+	for input, want := range map[string]string{
+		"":                        "unexpected EOF",
+		"3 x 1\n":                 "строка 1: заголовок",
+		"99999999999999 1 1\n":    "число вершин 99999999999999 больше допустимого",
+		"2 2 1\n\n0 1 1 0\n0 1\n": "строка 4: в описании дуги 2 чисел вместо 4",
+		"2 1 1\n" + strings.Repeat("1", maxGraphLineLength+1): "длиннее",
+	} {
+		_, _, _, err := ParseGraph(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%.20q: got error %v, want %q", input, err, want)
+		}
+	}
+}
+
+func BenchmarkParseGraph(b *testing.B) {
+	cfg := DefaultGeneratorConfig(BarrierLimit)
+	cfg.N, cfg.M, cfg.Seed = 100000, 300000, 1
+	graph, err := GenerateGraph(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf bytes.Buffer
+	WriteGraph(&buf, graph, BarrierLimit, cfg.Level)
+	b.Run("parser", func(b *testing.B) {
+		b.SetBytes(int64(buf.Len()))
+		for i := 0; i < b.N; i++ {
+			ParseGraph(bytes.NewReader(buf.Bytes()))
+		}
+	})
+	b.Run("fscanln", func(b *testing.B) {
+		b.SetBytes(int64(buf.Len()))
+		for i := 0; i < b.N; i++ {
+			fscanlnGraph(bytes.NewReader(buf.Bytes()))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// WithKind подставляет тип ограничения kind, если в файле он не указан
+func (h GraphHeader) WithKind(kind LimitType) GraphHeader {
+	if !h.Declared {
+		h.Kind = kind
+	}
+	return h
+}
+
+// Constraint возвращает ограничение для решателей; у mix уровня нет, третье число его заголовка -
+// число запрещенных дуг
+func (h GraphHeader) Constraint() Constraint {
+	if h.Kind == MixLimit {
+		return Constraint{MixLimit, 0}
+	}
+	return Constraint{h.Kind, h.Level}
+}
+
+// Validate проверяет третье число заголовка для типа ограничения Kind: число запрещенных дуг mix
+// и уровень остальных ограничений не бывают отрицательными, уровень mag не меньше 1
+func (h GraphHeader) Validate() error {
+	if h.Kind == MixLimit && h.Level < 0 {
+		return fmt.Errorf("число запрещенных дуг не может быть отрицательным: %d", h.Level)
+	}
+	return h.Constraint().Validate()
+}
+
+// promptLimitUnlessDeclared спрашивает тип ограничения, только если он не указан в заголовке файла
+func promptLimitUnlessDeclared(h GraphHeader) GraphHeader {
+	if h.Declared {
+		fmt.Println("тип ограничения -", h.Kind)
+		return h
+	}
+	var limitName string
+	fmt.Print("Введите тип ограничения (mix, bar, mag, magbar): ")
+	fmt.Fscan(os.Stdin, &limitName)
+	limit, err := ParseLimitType(limitName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	h = h.WithKind(limit)
+	if err := h.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return h
+}
+
+type ReaderOptions struct {
+	//Kind - тип ограничения для файлов, в заголовке которых он не указан
+	Kind LimitType
+	//Verbose печатает заголовок и список дуг
+	Verbose bool
+}
+
+var constraintHeaderLabels = [...]string{"число запрещенных дуг -", "уровень барьера -", "уровень магнитности -", "уровень магнитности -"}
+
+// ReadGraph читает граф в текстовом формате из любого источника. Как и прежние читатели, при ошибке
+// формата он возвращает дуги, прочитанные до нее, вместе с первой ошибкой; тип ограничения
+// в заголовке берется из файла, а если его там нет - из opts.Kind
+func ReadGraph(r io.Reader, opts ReaderOptions) ([][]trio, GraphHeader, error) {
+	graph, h, err := readGraph(NewGraphParser(r), opts)
+	h = h.WithKind(opts.Kind)
+	if err == nil {
+		err = h.Validate()
+	}
+	return graph, h, err
+}
+
+// readGraph читает заголовок и дуги, оставляя в p необязательные разделы после дуг
+func readGraph(p *GraphParser, opts ReaderOptions) ([][]trio, GraphHeader, error) {
+	h, headerErr := p.ReadHeader()
+	if opts.Verbose {
+		fmt.Println("число вершин в графе -", h.N)
+		fmt.Println("число дуг графа -", h.M)
+		if h.Declared {
+			fmt.Println("тип ограничения -", h.Kind)
+		}
+		fmt.Println(constraintHeaderLabels[h.WithKind(opts.Kind).Kind], h.Level)
+		fmt.Println("Список дуг:")
+	}
+	graph, err := p.ReadEdges(h.N, h.M, opts.Verbose)
+	if headerErr != nil {
+		err = headerErr
+	}
+	return graph, h, err
+}
+
+// ReadGraphFile открывает файл (в том числе сжатый) и читает граф; ошибку открытия файла
+// и ошибку формата возвращает одинаково, граф при этом может быть прочитан частично
+func ReadGraphFile(filename string, opts ReaderOptions) ([][]trio, GraphHeader, error) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		return nil, GraphHeader{Kind: opts.Kind}, err
+	}
+	defer f.Close()
+	return ReadGraph(f, opts)
+}
+
+// readGraphFileOrExit - поведение прежних ReadGraphFor*: без файла программа завершается,
+// а ошибки формата не прерывают работу
+func readGraphFileOrExit(filename string, opts ReaderOptions) ([][]trio, GraphHeader) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	graph, header, _ := ReadGraph(f, opts)
+	return graph, header
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadGraphDeclaredKind(t *testing.T) {
+	want := [][]trio{{{1, 5, Barrier}}, {{2, 3, Normal}}, nil}
+	for _, tc := range []struct {
+		input    string
+		fallback LimitType
+		header   GraphHeader
+	}{
+		{"3 2 2 bar\n0 1 5 3\n1 2 3 0\n", MixLimit, GraphHeader{N: 3, M: 2, Level: 2, Kind: BarrierLimit, Declared: true}},
+		{"3 2 2 magbar\n0 1 5 3\n1 2 3 0\n", BarrierLimit, GraphHeader{N: 3, M: 2, Level: 2, Kind: MagnetBarrierLimit, Declared: true}},
+		{"3 2 1 mix\n0 1 5 3\n1 2 3 0\n", BarrierLimit, GraphHeader{N: 3, M: 2, Level: 1, Kind: MixLimit, Declared: true}},
+		//в прежнем формате тип ограничения задает вызывающий
+		{"3 2 2\n0 1 5 3\n1 2 3 0\n", MagnetLimit, GraphHeader{N: 3, M: 2, Level: 2, Kind: MagnetLimit}},
+		{"3 2 1\n0 1 5 3\n1 2 3 0\n", MixLimit, GraphHeader{N: 3, M: 2, Level: 1, Kind: MixLimit}},
+	} {
+		graph, header, err := ReadGraph(strings.NewReader(tc.input), ReaderOptions{Kind: tc.fallback})
+		if err != nil {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+		if header != tc.header || !reflect.DeepEqual(graph, want) {
+			t.Errorf("%q: got %v %+v, want %+v", tc.input, graph, header, tc.header)
+		}
+	}
+}
+
+func TestGraphHeaderConstraint(t *testing.T) {
+	if c := (GraphHeader{Level: 4, Kind: MixLimit}).Constraint(); c != (Constraint{MixLimit, 0}) {
+		t.Errorf("mix: got %v", c)
+	}
+	if c := (GraphHeader{Level: 3, Kind: MagnetLimit}).Constraint(); c != (Constraint{MagnetLimit, 3}) {
+		t.Errorf("mag: got %v", c)
+	}
+}
+
+func TestLegacyReadersUseDeclaredKind(t *testing.T) {
+	//тип в заголовке не мешает прежним читателям, уровень читается так же
+	filename := writeTempGraph(t, "3 2 2 bar\n0 1 5 3\n1 2 3 0\n")
+	graph, level := ReadGraphForBarrierSpeedTest(filename)
+	if level != 2 || !reflect.DeepEqual(graph, [][]trio{{{1, 5, Barrier}}, {{2, 3, Normal}}, nil}) {
+		t.Fatalf("got %v %d", graph, level)
+	}
+}
+
+func TestReadGraphRejectsUnknownKind(t *testing.T) {
+	for _, input := range []string{"3 2 2 teleport\n0 1 5 0\n", "3 2 2 bar extra\n0 1 5 0\n"} {
+		if _, _, err := ReadGraph(strings.NewReader(input), ReaderOptions{}); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestWriteGraphRejectsMixWithOtherEdges(t *testing.T) {
+	var buf strings.Builder
+	graph := [][]trio{{{1, 5, Boosting}}, {{2, 3, Barrier}}, nil}
+	if err := WriteGraph(&buf, graph, MixLimit, 0); err == nil {
+		t.Fatal("mix header written for a graph with boosting and barrier edges")
+	}
+	if err := WriteGraph(&buf, graph, BarrierLimit, 1); err != nil || !strings.HasPrefix(buf.String(), "3 2 1 bar\n") {
+		t.Fatalf("got %q %v", buf.String(), err)
+	}
+}
+
+func TestReadGraphRejectsInvalidLevel(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		fallback LimitType
+	}{
+		{"3 2 -1 bar\n0 1 5 3\n1 2 3 0\n", MixLimit},
+		{"3 2 -1 mix\n0 1 5 0\n1 2 3 0\n", MixLimit},
+		{"3 2 0 mag\n0 1 5 2\n1 2 3 0\n", MixLimit},
+		{"3 2 -2 magbar\n0 1 5 2\n1 2 3 0\n", MixLimit},
+		//в прежнем формате уровень проверяется для типа, заданного вызывающим
+		{"3 2 0\n0 1 5 2\n1 2 3 0\n", MagnetLimit},
+		{"3 2 -1\n0 1 5 3\n1 2 3 0\n", BarrierLimit},
+	} {
+		if _, _, err := ReadGraph(strings.NewReader(tc.input), ReaderOptions{Kind: tc.fallback}); err == nil {
+			t.Errorf("%q: expected error", tc.input)
+		}
+	}
+	if _, err := NewGraphParser(strings.NewReader("3 2 0 mag\n")).ReadHeader(); err == nil || !strings.Contains(err.Error(), "строка 1: заголовок") {
+		t.Errorf("got %v", err)
+	}
+}
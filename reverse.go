@@ -0,0 +1,142 @@
+package main
+
+// ReversedGraph - обращенный граф Graph.Reversed() (EndPoint дуги - начальная вершина исходной дуги)
+// с ограничением, по которому переходы между уровнями обращаются через PrevLevels
+type ReversedGraph struct {
+	Constraint Constraint
+	Graph      *Graph
+}
+
+func MakeReversedGraph(graph *Graph, c Constraint) *ReversedGraph {
+	return &ReversedGraph{c, graph.Reversed()}
+}
+
+// PrevLevels возвращает уровни, с которых обращенная дуга e приводит на уровень level
+func (r *ReversedGraph) PrevLevels(e trio, level int) []int {
+	return PrevLevels(r.Constraint, e.EdgeType, level, r.Graph.MagnetVertex(e.EndPoint))
+}
+
+// PrevLevels возвращает уровни начальной вершины дуги типа edgeType, из которых
+// NextLevel приводит в level; magnetVertex относится к начальной вершине дуги
+func PrevLevels(c Constraint, edgeType EdgeType, level int, magnetVertex bool) []int {
+	return appendPrevLevels(nil, c, edgeType, level, magnetVertex)
+}
+
+// appendPrevLevels - PrevLevels без выделения памяти: уровней не больше двух, и поиск передает буфер на стеке
+func appendPrevLevels(levels []int, c Constraint, edgeType EdgeType, level int, magnetVertex bool) []int {
+	top := c.Levels() - 1
+	if level < 0 || level > top {
+		return levels
+	}
+	switch c.Type {
+	case MixLimit:
+		switch {
+		case edgeType == Normal && level == 0:
+			levels = append(levels, 0, 1)
+		case edgeType == Closed && level == 1:
+			levels = append(levels, 0)
+		}
+	case BarrierLimit:
+		switch edgeType {
+		case Normal:
+			levels = append(levels, level)
+		case Boosting:
+			if level > 0 {
+				levels = append(levels, level-1)
+			}
+			if level == top {
+				levels = append(levels, top)
+			}
+		case Barrier:
+			if level == 0 {
+				levels = append(levels, top)
+			}
+		}
+	case MagnetLimit, MagnetBarrierLimit:
+		//с уровней ниже верхнего
+		switch {
+		case edgeType == Normal && level < top:
+			levels = append(levels, level)
+		case edgeType == Magnet && level < top && c.Type == MagnetLimit:
+			levels = append(levels, level)
+		case edgeType == Boosting && level > 0:
+			levels = append(levels, level-1)
+		}
+		//с верхнего уровня
+		magnetLevel := top
+		if c.Type == MagnetLimit {
+			magnetLevel = top - 1
+		}
+		switch {
+		case magnetVertex && edgeType == Magnet && level == magnetLevel:
+			levels = append(levels, top)
+		case !magnetVertex && level == top:
+			levels = append(levels, top)
+		}
+	}
+	return levels
+}
+
+// DeijkstraBackwardAlgorithm возвращает dists[v][l] - расстояние с учетом ограничения
+// из состояния (v, l) до финиша, на каком бы уровне в него ни прийти
+func DeijkstraBackwardAlgorithm(graph *Graph, c Constraint, finishPoint int) [][]int {
+	reversed := MakeReversedGraph(graph, c)
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	finishes := make([]Source, c.Levels())
+	for l := range finishes {
+		finishes[l] = Source{finishPoint, l, 0}
+	}
+	w.reset(graph.N(), c.Levels(), finishes, nil)
+	for {
+		v, level, ok := w.next()
+		if !ok {
+			break
+		}
+		w.expandBackward(reversed, v, level)
+	}
+	return w.potential()
+}
+
+// expandBackward проходит входящие дуги извлеченного состояния (v, level) по обращенному графу:
+// начальная вершина дуги получает уровни PrevLevels
+func (w *vectorWorkspace) expandBackward(r *ReversedGraph, v int, level int) {
+	in, dist := r.Graph, w.dist(v, level)
+	var prevLevels [2]int
+	for i := in.Offsets[v]; i < in.Offsets[v+1]; i++ {
+		from := int(in.Targets[i])
+		for _, l := range appendPrevLevels(prevLevels[:0], r.Constraint, EdgeType(in.Types[i]), level, in.MagnetVertex(from)) {
+			w.relaxState(from, l, dist+in.Weights[i], v, level, i)
+		}
+	}
+}
+
+// DistancesToTarget возвращает расстояния до финиша из каждой вершины, начиная с уровня 0
+func DistancesToTarget(graph [][]trio, c Constraint, finishPoint int) []int {
+	return DistancesToTargetOnGraph(NewGraph(graph), c, finishPoint)
+}
+
+func DistancesToTargetOnGraph(graph *Graph, c Constraint, finishPoint int) []int {
+	dists := DeijkstraBackwardAlgorithm(graph, c, finishPoint)
+	toTarget := make([]int, len(dists))
+	for v := range dists {
+		toTarget[v] = dists[v][0]
+	}
+	return toTarget
+}
+
+// SourcesReachingTarget оставляет из sources вершины, из которых финиш достижим с учетом ограничения
+func SourcesReachingTarget(graph [][]trio, c Constraint, finishPoint int, sources []int) []int {
+	return SourcesReachingTargetOnGraph(NewGraph(graph), c, finishPoint, sources)
+}
+
+func SourcesReachingTargetOnGraph(graph *Graph, c Constraint, finishPoint int, sources []int) []int {
+	toTarget := DistancesToTargetOnGraph(graph, c, finishPoint)
+	reaching := make([]int, 0, len(sources))
+	for _, v := range sources {
+		if toTarget[v] != int(^uint(0)>>1) {
+			reaching = append(reaching, v)
+		}
+	}
+	return reaching
+}
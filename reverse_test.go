@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestPrevLevelsInvertsNextLevel(t *testing.T) {
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for level := 0; level < 4; level++ {
+			c := Constraint{limit, level}
+			for edgeType := Normal; edgeType <= Magnet; edgeType++ {
+				for _, magnetVertex := range []bool{false, true} {
+					for to := 0; to < c.Levels(); to++ {
+						var want []int
+						for from := 0; from < c.Levels(); from++ {
+							if next, ok := NextLevel(c, edgeType, from, magnetVertex); ok && next == to {
+								want = append(want, from)
+							}
+						}
+						got := PrevLevels(c, edgeType, to, magnetVertex)
+						sort.Ints(got)
+						if !equalInts(got, want) {
+							t.Errorf("%v level %d: edge %v into %d (magnet %v): got %v, want %v",
+								limit, level, edgeType, to, magnetVertex, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBackwardDistancesMatchOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 500; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			c := Constraint{limit, level}
+			finish := rnd.Intn(len(graph))
+			toTarget := DistancesToTarget(graph, c, finish)
+			var reaching []int
+			for v := range graph {
+				want := oracleDistance(graph, limit, level, v, finish)
+				if toTarget[v] != want {
+					t.Fatalf("%v level %d: from %d to %d got %d, want %d\n%v", limit, level, v, finish, toTarget[v], want, graph)
+				}
+				if want != inf {
+					reaching = append(reaching, v)
+				}
+			}
+			all := make([]int, len(graph))
+			for v := range all {
+				all[v] = v
+			}
+			if got := SourcesReachingTarget(graph, c, finish, all); !equalInts(got, reaching) {
+				t.Fatalf("%v level %d: sources reaching %d: got %v, want %v", limit, level, finish, got, reaching)
+			}
+		}
+	}
+}
+
+func BenchmarkDistancesToTarget(b *testing.B) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 4000)
+		b.Run(fmt.Sprint(limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				DistancesToTargetOnGraph(g, c, g.N()-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ServedGraph - граф, загруженный сервером; после запуска он только читается всеми запросами
+type ServedGraph struct {
+	Name   string
+	Graph  *Graph
+	Header GraphHeader
+}
+
+type servedGraph struct {
+	ServedGraph
+	//mixEdges: в графе только обычные и закрытые дуги, и для него можно искать путь с ограничением mix
+	mixEdges bool
+	//maxLevel ограничивает уровень в запросе, так как память векторного алгоритма растет как n*(level+1)
+	maxLevel int
+}
+
+// maxRouteStates - число состояний n*(level+1), до которого сервер принимает уровень из запроса;
+// уровень из заголовка файла допускается всегда, так как граф с ним уже загружен
+const maxRouteStates = 1 << 24
+
+// RouteServer отвечает на запросы маршрутов по графам, загруженным при запуске
+type RouteServer struct {
+	graphs map[string]*servedGraph
+	names  []string
+	mux    *http.ServeMux
+}
+
+func NewRouteServer(graphs []ServedGraph) (*RouteServer, error) {
+	s := &RouteServer{graphs: make(map[string]*servedGraph, len(graphs)), mux: http.NewServeMux()}
+	for _, g := range graphs {
+		if _, ok := s.graphs[g.Name]; ok {
+			return nil, fmt.Errorf("граф %q загружен дважды", g.Name)
+		}
+		served := &servedGraph{ServedGraph: g, mixEdges: true}
+		for _, t := range g.Graph.Types {
+			if EdgeType(t) != Normal && EdgeType(t) != Closed {
+				served.mixEdges = false
+			}
+		}
+		served.maxLevel = g.Header.Level
+		if n := g.Graph.N(); n > 0 && maxRouteStates/n-1 > served.maxLevel {
+			served.maxLevel = maxRouteStates/n - 1
+		}
+		s.graphs[g.Name] = served
+		s.names = append(s.names, g.Name)
+	}
+	sort.Strings(s.names)
+	s.mux.HandleFunc("POST /route", s.handleRoute)
+	s.mux.HandleFunc("GET /graphs", s.handleGraphs)
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	return s, nil
+}
+
+func (s *RouteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// vertexID - вершина в запросе: имя или номер, записанный строкой или числом
+type vertexID string
+
+func (v *vertexID) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = vertexID(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("вершина должна быть именем или номером: %s", data)
+	}
+	*v = vertexID(s)
+	return nil
+}
+
+// RouteRequest: Graph можно не указывать, если загружен один граф; без Constraint берется тип ограничения
+// из заголовка файла, а без Level - уровень из заголовка, если тип совпадает с объявленным в файле
+// (для mix уровень не нужен)
+type RouteRequest struct {
+	Graph      string   `json:"graph"`
+	Start      vertexID `json:"start"`
+	Finish     vertexID `json:"finish"`
+	Constraint string   `json:"constraint"`
+	Level      *int     `json:"level"`
+}
+
+// RouteResponse: для недостижимого финиша Reachable == false, а пути и расстояния нет
+type RouteResponse struct {
+	Graph      string   `json:"graph"`
+	Constraint string   `json:"constraint"`
+	Level      int      `json:"level"`
+	Reachable  bool     `json:"reachable"`
+	Distance   *int     `json:"distance,omitempty"`
+	Path       []string `json:"path,omitempty"`
+	Vertices   []int    `json:"vertices,omitempty"`
+}
+
+type GraphInfo struct {
+	Name        string `json:"name"`
+	Vertices    int    `json:"vertices"`
+	Edges       int    `json:"edges"`
+	Constraint  string `json:"constraint,omitempty"`
+	Level       int    `json:"level"`
+	Labels      bool   `json:"labels"`
+	Coordinates bool   `json:"coordinates"`
+}
+
+type serveError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, serveError{fmt.Sprintf(format, args...)})
+}
+
+func (s *RouteServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *RouteServer) handleGraphs(w http.ResponseWriter, r *http.Request) {
+	infos := make([]GraphInfo, 0, len(s.names))
+	for _, name := range s.names {
+		g := s.graphs[name]
+		info := GraphInfo{
+			Name:        name,
+			Vertices:    g.Graph.N(),
+			Edges:       g.Graph.M(),
+			Level:       g.Header.Level,
+			Labels:      g.Graph.Labels() != nil,
+			Coordinates: g.Graph.Coordinates() != nil,
+		}
+		if g.Header.Declared {
+			info.Constraint = g.Header.Kind.String()
+		}
+		infos = append(infos, info)
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *RouteServer) handleRoute(w http.ResponseWriter, r *http.Request) {
+	var req RouteRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "недопустимый запрос: %v", err)
+		return
+	}
+
+	if req.Graph == "" && len(s.names) == 1 {
+		req.Graph = s.names[0]
+	}
+	g, ok := s.graphs[req.Graph]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "граф %q не загружен", req.Graph)
+		return
+	}
+
+	limit := g.Header.Kind
+	if req.Constraint != "" {
+		var err error
+		if limit, err = ParseLimitType(req.Constraint); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+	} else if !g.Header.Declared {
+		writeJSONError(w, http.StatusBadRequest, "в файле графа %q тип ограничения не указан, нужен constraint", req.Graph)
+		return
+	}
+	//уровень из заголовка относится только к объявленному в файле типу ограничения
+	c := Constraint{Type: limit}
+	switch {
+	case limit == MixLimit:
+	case req.Level != nil:
+		c.Level = *req.Level
+	case g.Header.Declared && limit == g.Header.Kind:
+		c.Level = g.Header.Level
+	default:
+		writeJSONError(w, http.StatusBadRequest, "для ограничения %v в графе %q нужен level", limit, req.Graph)
+		return
+	}
+	if err := c.Validate(); err != nil || c.Level > g.maxLevel {
+		writeJSONError(w, http.StatusBadRequest, "недопустимый уровень %d", c.Level)
+		return
+	}
+	if limit == MixLimit && !g.mixEdges {
+		writeJSONError(w, http.StatusBadRequest, "в графе %q есть дуги, недопустимые для ограничения mix", req.Graph)
+		return
+	}
+
+	start, err := g.Graph.VertexIndex(string(req.Start))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "начальная вершина: %v", err)
+		return
+	}
+	finish, err := g.Graph.VertexIndex(string(req.Finish))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "конечная вершина: %v", err)
+		return
+	}
+
+	path, dist := RouteOnGraph(g.Graph, c, start, finish)
+
+	resp := RouteResponse{Graph: req.Graph, Constraint: limit.String(), Level: c.Level}
+	if dist != int(^uint(0)>>1) {
+		resp.Reachable = true
+		resp.Distance = &dist
+		resp.Path = g.Graph.PathLabels(path)
+		resp.Vertices = path
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// servedGraphName - имя графа по имени файла без каталога и расширений: roads.txt.gz -> roads
+func servedGraphName(filename string) string {
+	name := filepath.Base(filename)
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// LoadServedGraph читает граф в текстовом формате или снимок; у снимка тип ограничения всегда указан
+func LoadServedGraph(filename string) (ServedGraph, error) {
+	snapshot, err := IsSnapshotFile(filename)
+	if err != nil {
+		return ServedGraph{}, err
+	}
+	if snapshot {
+		g, c, err := LoadSnapshot(filename)
+		if err != nil {
+			return ServedGraph{}, err
+		}
+		header := GraphHeader{N: g.N(), M: g.M(), Level: c.Level, Kind: c.Type, Declared: true}
+		return ServedGraph{servedGraphName(filename), g, header}, nil
+	}
+	g, header, err := LoadGraphFile(filename)
+	if err != nil {
+		return ServedGraph{}, err
+	}
+	return ServedGraph{servedGraphName(filename), g, header}, nil
+}
+
+func ServeProgramm() {
+	var addr string
+	var count int
+
+	fmt.Print("Введите адрес сервера (например, :8080) и число графов: ")
+	fmt.Fscan(os.Stdin, &addr, &count)
+	graphs := make([]ServedGraph, 0, count)
+	for i := 0; i < count; i++ {
+		var filename string
+		fmt.Printf("Введите имя файла с графом %d: ", i+1)
+		fmt.Fscan(os.Stdin, &filename)
+		g, err := LoadServedGraph(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graphs = append(graphs, g)
+		fmt.Println("Загружен граф", g.Name, ": вершин -", g.Graph.N(), ", дуг -", g.Graph.M())
+	}
+	server, err := NewRouteServer(graphs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Сервер слушает", addr)
+	log.Fatal(http.ListenAndServe(addr, server))
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestRouteServer(t *testing.T) *httptest.Server {
+	//в графе roads есть дуги ускорения и барьера, поэтому mix проверяется на отдельном графе
+	labeled, header, err := LoadGraphFile(writeTempGraph(t, "4 4 1 bar\n0 1 5 0\n1 2 3 2\n0 2 1 3\n2 3 2 0\nlabels\n0 MSK\n1 TVR\n2 SPB\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mix, mixHeader, err := LoadGraphFile(writeTempGraph(t, "3 3 1\n0 1 4 1\n1 2 5 0\n0 2 20 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//в графе ring тип mix объявлен, и уровень из его заголовка - число закрытых дуг, а не уровень bar
+	ring, ringHeader, err := LoadGraphFile(writeTempGraph(t, "3 3 1 mix\n0 1 4 1\n1 2 5 0\n2 0 20 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewRouteServer([]ServedGraph{{"roads", labeled, header}, {"mix", mix, mixHeader}, {"ring", ring, ringHeader}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postRoute(t *testing.T, url string, body string) (int, RouteResponse, serveError) {
+	resp, err := http.Post(url+"/route", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var route RouteResponse
+	var failure serveError
+	var raw bytes.Buffer
+	raw.ReadFrom(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		err = json.Unmarshal(raw.Bytes(), &route)
+	} else {
+		err = json.Unmarshal(raw.Bytes(), &failure)
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", raw.String(), err)
+	}
+	return resp.StatusCode, route, failure
+}
+
+func TestRouteServerRoute(t *testing.T) {
+	ts := newTestRouteServer(t)
+
+	//тип и уровень из заголовка файла, вершины по именам и номерам
+	status, route, _ := postRoute(t, ts.URL, `{"graph": "roads", "start": "MSK", "finish": 3}`)
+	if status != http.StatusOK || !route.Reachable || *route.Distance != 10 || route.Constraint != "bar" || route.Level != 1 {
+		t.Fatalf("got %d %+v", status, route)
+	}
+	if !reflect.DeepEqual(route.Path, []string{"MSK", "TVR", "SPB", "3"}) || !reflect.DeepEqual(route.Vertices, []int{0, 1, 2, 3}) {
+		t.Fatalf("got path %v %v", route.Path, route.Vertices)
+	}
+
+	//без ускорения барьер не пройти
+	status, route, _ = postRoute(t, ts.URL, `{"graph": "roads", "start": "TVR", "finish": "MSK", "constraint": "bar", "level": 0}`)
+	if status != http.StatusOK || route.Reachable || route.Distance != nil || route.Path != nil {
+		t.Fatalf("got %d %+v", status, route)
+	}
+
+	//тип из запроса заменяет тип из заголовка файла
+	status, route, _ = postRoute(t, ts.URL, `{"graph": "roads", "start": "MSK", "finish": 3, "constraint": "mag", "level": 1}`)
+	if status != http.StatusOK || route.Constraint != "mag" || route.Level != 1 {
+		t.Fatalf("mag: got %d %+v", status, route)
+	}
+
+	//уровень из запроса ограничен числом состояний, а не уровнем из заголовка
+	status, route, _ = postRoute(t, ts.URL, `{"graph": "roads", "start": "MSK", "finish": 3, "level": 5}`)
+	if status != http.StatusOK || !route.Reachable || route.Level != 5 {
+		t.Fatalf("level 5: got %d %+v", status, route)
+	}
+
+	//для bar на графе с объявленным mix уровень берется из запроса
+	status, route, _ = postRoute(t, ts.URL, `{"graph": "ring", "start": 1, "finish": 0, "constraint": "bar", "level": 0}`)
+	if status != http.StatusOK || !route.Reachable || *route.Distance != 25 || route.Level != 0 {
+		t.Fatalf("ring bar: got %d %+v", status, route)
+	}
+
+	//mix: одна закрытая дуга допускается
+	status, route, _ = postRoute(t, ts.URL, `{"graph": "mix", "start": 0, "finish": 2, "constraint": "mix"}`)
+	if status != http.StatusOK || !route.Reachable || *route.Distance != 9 || !reflect.DeepEqual(route.Vertices, []int{0, 1, 2}) {
+		t.Fatalf("mix: got %d %+v", status, route)
+	}
+}
+
+func TestRouteServerRejectsInvalidRequests(t *testing.T) {
+	ts := newTestRouteServer(t)
+	for body, want := range map[string]struct {
+		status int
+		error  string
+	}{
+		`{"start": 0, "finish": 1}`:                                                    {http.StatusNotFound, "не загружен"},
+		`{"graph": "roads", "start": "MSK", "finish": "OMSK"}`:                         {http.StatusBadRequest, "конечная вершина"},
+		`{"graph": "roads", "start": 7, "finish": 0}`:                                  {http.StatusBadRequest, "начальная вершина"},
+		`{"graph": "roads", "start": 0, "finish": 1, "constraint": "warp"}`:            {http.StatusBadRequest, "неизвестный тип ограничения"},
+		`{"graph": "roads", "start": 0, "finish": 1, "level": -1}`:                     {http.StatusBadRequest, "недопустимый уровень"},
+		`{"graph": "roads", "start": 0, "finish": 1, "level": 100000000}`:              {http.StatusBadRequest, "недопустимый уровень"},
+		`{"graph": "roads", "start": 0, "finish": 1, "level": 4611686018427387904}`:    {http.StatusBadRequest, "недопустимый уровень"},
+		`{"graph": "roads", "start": 0, "finish": 1, "constraint": "mag", "level": 0}`: {http.StatusBadRequest, "недопустимый уровень"},
+		`{"graph": "roads", "start": 0, "finish": 1, "constraint": "mag"}`:             {http.StatusBadRequest, "нужен level"},
+		`{"graph": "ring", "start": 0, "finish": 1, "constraint": "bar"}`:              {http.StatusBadRequest, "нужен level"},
+		`{"graph": "ring", "start": 0, "finish": 1, "constraint": "magbar"}`:           {http.StatusBadRequest, "нужен level"},
+		`{"graph": "roads", "start": 0, "finish": 1, "constraint": "mix"}`:             {http.StatusBadRequest, "недопустимые для ограничения mix"},
+		`{"graph": "mix", "start": 0, "finish": 1}`:                                    {http.StatusBadRequest, "нужен constraint"},
+		`{"graph": "roads", "start": 0, "finish": 1, "speed": 3}`:                      {http.StatusBadRequest, "недопустимый запрос"},
+		`{"graph": "roads", "start": [0], "finish": 1}`:                                {http.StatusBadRequest, "недопустимый запрос"},
+	} {
+		status, _, failure := postRoute(t, ts.URL, body)
+		if status != want.status || !strings.Contains(failure.Error, want.error) {
+			t.Errorf("%s: got %d %q, want %d %q", body, status, failure.Error, want.status, want.error)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /route: got %d", resp.StatusCode)
+	}
+}
+
+func TestLoadServedGraphReadsSnapshots(t *testing.T) {
+	text := writeTempGraph(t, "3 2 1 mag\n0 1 5 2\n1 2 3 0\nlabels\n0 MSK\n2 SPB\n")
+	g, header, err := LoadGraphFile(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(t.TempDir(), "roads.csrg")
+	if err := WriteSnapshotFile(snapshot, g, header.Constraint()); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{text, snapshot} {
+		served, err := LoadServedGraph(filename)
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		if served.Name != servedGraphName(filename) || served.Header != header || !reflect.DeepEqual(served.Graph.AdjacencyList(), g.AdjacencyList()) {
+			t.Errorf("%s: got %+v", filename, served)
+		}
+		//маршрут по именам одинаков для текстового файла и для снимка
+		if path, _, err := RouteByName(served.Graph, header.Constraint(), "MSK", "SPB"); err != nil || !reflect.DeepEqual(path, []string{"MSK", "1", "SPB"}) {
+			t.Errorf("%s: got %v %v", filename, path, err)
+		}
+	}
+}
+
+func TestRouteServerGraphsAndHealthz(t *testing.T) {
+	ts := newTestRouteServer(t)
+	resp, err := http.Get(ts.URL + "/graphs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var infos []GraphInfo
+	if err := json.NewDecoder(resp.Body).Decode(&infos); err != nil {
+		t.Fatal(err)
+	}
+	want := []GraphInfo{
+		{Name: "mix", Vertices: 3, Edges: 3, Level: 1},
+		{Name: "ring", Vertices: 3, Edges: 3, Constraint: "mix", Level: 1},
+		{Name: "roads", Vertices: 4, Edges: 4, Constraint: "bar", Level: 1, Labels: true},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Fatalf("got %+v", infos)
+	}
+
+	resp, err = http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("healthz: got %d", resp.StatusCode)
+	}
+
+	if _, err := NewRouteServer([]ServedGraph{{"a", NewGraph(nil), GraphHeader{}}, {"a", NewGraph(nil), GraphHeader{}}}); err == nil {
+		t.Fatal("expected error for duplicate graph names")
+	}
+}
+
+func TestRouteServerConcurrentRequests(t *testing.T) {
+	rnd := rand.New(rand.NewSource(50))
+	adj, level := randomTestGraph(rnd, MagnetBarrierLimit)
+	for len(adj) < 6 {
+		adj, level = randomTestGraph(rnd, MagnetBarrierLimit)
+	}
+	g := NewGraph(adj)
+	server, err := NewRouteServer([]ServedGraph{{"random", g, GraphHeader{N: g.N(), M: g.M(), Level: level, Kind: MagnetBarrierLimit, Declared: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for start := 0; start < g.N(); start++ {
+		for finish := 0; finish < g.N(); finish++ {
+			wg.Add(1)
+			go func(start, finish int) {
+				defer wg.Done()
+				body, _ := json.Marshal(map[string]int{"start": start, "finish": finish})
+				resp, err := http.Post(ts.URL+"/route", "application/json", bytes.NewReader(body))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer resp.Body.Close()
+				var route RouteResponse
+				if err := json.NewDecoder(resp.Body).Decode(&route); err != nil {
+					t.Error(err)
+					return
+				}
+				_, dist := RouteOnGraph(g, Constraint{MagnetBarrierLimit, level}, start, finish)
+				if reachable := dist != int(^uint(0)>>1); reachable != route.Reachable || reachable && *route.Distance != dist {
+					t.Errorf("%d -> %d: got %+v, want %d", start, finish, route, dist)
+				}
+			}(start, finish)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"math"
+	"os"
+	"time"
+)
+
+// Снимок графа (little-endian):
+//
+//	заголовок: сигнатура "CSRG", версия (uint32), тип ограничения (uint32), уровень (int32), n (uint64), m (uint64)
+//	Offsets: n+1 чисел uint64, Targets: m чисел uint32, Weights: m чисел int64, Types: m байт
+//	разделы вершин: флаги (uint8: 1 - имена, 2 - координаты), затем для каждой вершины
+//	имя - длина (uint32) и байты, затем для каждой вершины координаты X и Y (float64)
+//	контрольная сумма CRC-32 (IEEE) всех предыдущих байт (uint32)
+const (
+	snapshotVersion    = 2
+	snapshotHeaderSize = 32
+)
+
+const (
+	snapshotLabels uint8 = 1 << iota
+	snapshotCoords
+)
+
+var snapshotMagic = [4]byte{'C', 'S', 'R', 'G'}
+
+// WriteSnapshot записывает дуги, ограничение, а также имена и координаты вершин, если они есть у графа
+func WriteSnapshot(w io.Writer, g *Graph, c Constraint) error {
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	header := make([]byte, snapshotHeaderSize)
+	copy(header, snapshotMagic[:])
+	binary.LittleEndian.PutUint32(header[4:], snapshotVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(c.Type))
+	binary.LittleEndian.PutUint32(header[12:], uint32(int32(c.Level)))
+	binary.LittleEndian.PutUint64(header[16:], uint64(g.N()))
+	binary.LittleEndian.PutUint64(header[24:], uint64(g.M()))
+	bw.Write(header)
+
+	buf := make([]byte, 8)
+	for _, o := range g.Offsets {
+		binary.LittleEndian.PutUint64(buf, uint64(o))
+		bw.Write(buf)
+	}
+	for _, t := range g.Targets {
+		binary.LittleEndian.PutUint32(buf, uint32(t))
+		bw.Write(buf[:4])
+	}
+	for _, wt := range g.Weights {
+		binary.LittleEndian.PutUint64(buf, uint64(int64(wt)))
+		bw.Write(buf)
+	}
+	bw.Write(g.Types)
+
+	var flags uint8
+	if g.Labels() != nil {
+		flags |= snapshotLabels
+	}
+	if g.Coordinates() != nil {
+		flags |= snapshotCoords
+	}
+	bw.WriteByte(flags)
+	for _, name := range g.Labels() {
+		binary.LittleEndian.PutUint32(buf, uint32(len(name)))
+		bw.Write(buf[:4])
+		bw.WriteString(name)
+	}
+	for _, c := range g.Coordinates() {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(c.X))
+		bw.Write(buf)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(c.Y))
+		bw.Write(buf)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(buf, crc.Sum32())
+	_, err := w.Write(buf[:4])
+	return err
+}
+
+func WriteSnapshotFile(filename string, g *Graph, c Constraint) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteSnapshot(f, g, c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DecodeSnapshot разбирает снимок, целиком находящийся в памяти, и проверяет его целостность
+func DecodeSnapshot(data []byte) (*Graph, Constraint, error) {
+	if len(data) < snapshotHeaderSize+4 {
+		return nil, Constraint{}, errors.New("снимок графа слишком короткий")
+	}
+	if [4]byte{data[0], data[1], data[2], data[3]} != snapshotMagic {
+		return nil, Constraint{}, errors.New("неизвестный формат снимка графа")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != snapshotVersion {
+		return nil, Constraint{}, fmt.Errorf("неподдерживаемая версия снимка графа: %d", version)
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, Constraint{}, errors.New("контрольная сумма снимка графа не совпадает")
+	}
+
+	c := Constraint{LimitType(binary.LittleEndian.Uint32(data[8:])), int(int32(binary.LittleEndian.Uint32(data[12:])))}
+	if c.Validate() != nil || c.Level < 0 {
+		return nil, Constraint{}, fmt.Errorf("недопустимое ограничение в снимке графа: %v, уровень %d", c.Type, c.Level)
+	}
+	n, m := binary.LittleEndian.Uint64(data[16:]), binary.LittleEndian.Uint64(data[24:])
+	//размеры проверяются до выделения памяти, чтобы испорченный заголовок не приводил к переполнению
+	rest := uint64(len(body) - snapshotHeaderSize)
+	if n >= rest/8 || m > rest/13 || 8*(n+1)+13*m+1 > rest {
+		return nil, Constraint{}, fmt.Errorf("размер снимка не соответствует заголовку: n=%d, m=%d", n, m)
+	}
+
+	g := &Graph{
+		Offsets: make([]int, n+1),
+		Targets: make([]int32, m),
+		Weights: make([]int, m),
+		Types:   make([]uint8, m),
+	}
+	p := body[snapshotHeaderSize:]
+	for i := range g.Offsets {
+		g.Offsets[i] = int(binary.LittleEndian.Uint64(p[8*i:]))
+		if i == 0 && g.Offsets[i] != 0 || i > 0 && (g.Offsets[i] < g.Offsets[i-1] || g.Offsets[i] > int(m)) {
+			return nil, Constraint{}, fmt.Errorf("недопустимое смещение дуг вершины %d", i)
+		}
+	}
+	if g.Offsets[n] != int(m) {
+		return nil, Constraint{}, errors.New("смещения дуг не покрывают все дуги")
+	}
+	p = p[8*(n+1):]
+	for i := range g.Targets {
+		g.Targets[i] = int32(binary.LittleEndian.Uint32(p[4*i:]))
+		if g.Targets[i] < 0 || uint64(g.Targets[i]) >= n {
+			return nil, Constraint{}, fmt.Errorf("дуга %d ведет в несуществующую вершину %d", i, g.Targets[i])
+		}
+	}
+	p = p[4*m:]
+	for i := range g.Weights {
+		g.Weights[i] = int(int64(binary.LittleEndian.Uint64(p[8*i:])))
+		if g.Weights[i] < 0 {
+			return nil, Constraint{}, fmt.Errorf("дуга %d имеет отрицательный вес", i)
+		}
+	}
+	copy(g.Types, p[8*m:])
+	for i, t := range g.Types {
+		if EdgeType(t) > Magnet {
+			return nil, Constraint{}, fmt.Errorf("дуга %d имеет неизвестный тип %d", i, t)
+		}
+	}
+	g.indexMagnetVertices()
+	g.reversed = new(reversedCache)
+
+	vertexData, err := decodeSnapshotVertexData(p[9*m:], int(n))
+	if err != nil {
+		return nil, Constraint{}, err
+	}
+	if vertexData.Labels != nil {
+		if g, err = g.WithLabels(vertexData.Labels); err != nil {
+			return nil, Constraint{}, err
+		}
+	}
+	if vertexData.Coords != nil {
+		if g, err = g.WithCoordinates(vertexData.Coords); err != nil {
+			return nil, Constraint{}, err
+		}
+	}
+	return g, c, nil
+}
+
+// decodeSnapshotVertexData разбирает разделы имен и координат; длины проверяются до выделения памяти
+func decodeSnapshotVertexData(p []byte, n int) (VertexData, error) {
+	var data VertexData
+	flags := p[0]
+	p = p[1:]
+	if flags&^(snapshotLabels|snapshotCoords) != 0 {
+		return data, fmt.Errorf("неизвестные разделы вершин в снимке графа: %#x", flags)
+	}
+	if flags&snapshotLabels != 0 {
+		if len(p)/4 < n {
+			return data, errors.New("раздел имен вершин снимка короче числа вершин")
+		}
+		data.Labels = make([]string, n)
+		for v := range data.Labels {
+			if len(p) < 4 || uint64(binary.LittleEndian.Uint32(p)) > uint64(len(p)-4) {
+				return data, fmt.Errorf("имя вершины %d выходит за конец снимка", v)
+			}
+			size := int(binary.LittleEndian.Uint32(p))
+			data.Labels[v] = string(p[4 : 4+size])
+			p = p[4+size:]
+		}
+	}
+	if flags&snapshotCoords != 0 {
+		if len(p) != 16*n {
+			return data, errors.New("размер раздела координат снимка не соответствует числу вершин")
+		}
+		data.Coords = make([]Coordinates, n)
+		for v := range data.Coords {
+			data.Coords[v] = Coordinates{
+				math.Float64frombits(binary.LittleEndian.Uint64(p[16*v:])),
+				math.Float64frombits(binary.LittleEndian.Uint64(p[16*v+8:])),
+			}
+		}
+		p = p[16*n:]
+	}
+	if len(p) != 0 {
+		return data, fmt.Errorf("лишние %d байт в конце снимка графа", len(p))
+	}
+	return data, nil
+}
+
+func ReadSnapshot(r io.Reader) (*Graph, Constraint, error) {
+	r, err := Decompress(r)
+	if err != nil {
+		return nil, Constraint{}, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, Constraint{}, err
+	}
+	return DecodeSnapshot(data)
+}
+
+// LoadSnapshot читает файл снимка одним вызовом и разбирает его без промежуточного буфера
+// (сжатый снимок сначала распаковывается в память);
+// отображение в память (mmap) не используется, так как оно не переносимо между ОС
+func LoadSnapshot(filename string) (*Graph, Constraint, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, Constraint{}, err
+	}
+	if data, err = decompressBytes(data); err != nil {
+		return nil, Constraint{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return DecodeSnapshot(data)
+}
+
+// IsSnapshotFile проверяет по сигнатуре, что файл (возможно, сжатый) является снимком графа
+func IsSnapshotFile(filename string) (bool, error) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	head := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(f, head); err == io.EOF || err == io.ErrUnexpectedEOF {
+		//файл короче сигнатуры снимком быть не может
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("%s: %w", filename, err)
+	}
+	return bytes.Equal(head, snapshotMagic[:]), nil
+}
+
+func SnapshotProgramm() {
+	var filename, outFilename string
+
+	fmt.Print("Введите имя текстового файла с графом и имя файла снимка: ")
+	fmt.Fscan(os.Stdin, &filename, &outFilename)
+
+	f, err := OpenInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := NewGraphParser(f)
+	graph, h, err := loadGraph(p)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Чтение текстового файла:", p.Stats())
+	header := promptLimitUnlessDeclared(h)
+	if err := WriteSnapshotFile(outFilename, graph, header.Constraint()); err != nil {
+		log.Fatal(err)
+	}
+
+	start := time.Now()
+	g, _, err := LoadSnapshot(outFilename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Время загрузки снимка:", time.Since(start))
+	fmt.Println("число вершин в графе -", g.N())
+	fmt.Println("число дуг графа -", g.M())
+}
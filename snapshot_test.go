@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func snapshotBytes(t testing.TB, g *Graph, c Constraint) []byte {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, g, c); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// resign пересчитывает контрольную сумму после правки снимка
+func resign(data []byte) {
+	binary.LittleEndian.PutUint32(data[len(data)-4:], crc32.ChecksumIEEE(data[:len(data)-4]))
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(18))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 100; i++ {
+			adj, level := randomTestGraph(rnd, limit)
+			g, c := NewGraph(adj), Constraint{limit, level}
+			loaded, loadedC, err := DecodeSnapshot(snapshotBytes(t, g, c))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loadedC != c || !reflect.DeepEqual(loaded, g) {
+				t.Fatalf("got %+v %v, want %+v %v", loaded, loadedC, g, c)
+			}
+		}
+	}
+
+	adj, level := randomTestGraph(rnd, MagnetLimit)
+	filename := filepath.Join(t.TempDir(), "graph.csr")
+	if err := WriteSnapshotFile(filename, NewGraph(adj), Constraint{MagnetLimit, level}); err != nil {
+		t.Fatal(err)
+	}
+	if g, _, err := LoadSnapshot(filename); err != nil || !reflect.DeepEqual(g, NewGraph(adj)) {
+		t.Fatalf("file round trip: %v", err)
+	}
+}
+
+func TestSnapshotRejectsCorruption(t *testing.T) {
+	adj := [][]trio{{{1, 5, Boosting}}, {{0, 3, Barrier}}}
+	valid := snapshotBytes(t, NewGraph(adj), Constraint{BarrierLimit, 1})
+	corrupt := func(name string, change func(data []byte) []byte, want string) {
+		data := change(append([]byte(nil), valid...))
+		if _, _, err := DecodeSnapshot(data); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want %q", name, err, want)
+		}
+	}
+	corrupt("flipped bit", func(d []byte) []byte { d[40] ^= 1; return d }, "контрольная сумма")
+	corrupt("truncated", func(d []byte) []byte { return d[:20] }, "слишком короткий")
+	corrupt("magic", func(d []byte) []byte { d[0] = 'X'; return d }, "неизвестный формат")
+	corrupt("version", func(d []byte) []byte { d[4] = 9; return d }, "версия")
+	corrupt("huge n", func(d []byte) []byte {
+		binary.LittleEndian.PutUint64(d[16:], 1<<62)
+		resign(d)
+		return d
+	}, "размер снимка")
+	corrupt("target", func(d []byte) []byte {
+		binary.LittleEndian.PutUint32(d[snapshotHeaderSize+8*3:], 7)
+		resign(d)
+		return d
+	}, "несуществующую вершину")
+	//за типами дуг идут флаги разделов вершин и контрольная сумма
+	corrupt("type", func(d []byte) []byte {
+		d[len(d)-6] = 9
+		resign(d)
+		return d
+	}, "неизвестный тип")
+	corrupt("vertex flags", func(d []byte) []byte {
+		d[len(d)-5] = 4
+		resign(d)
+		return d
+	}, "неизвестные разделы вершин")
+	corrupt("missing labels", func(d []byte) []byte {
+		d[len(d)-5] = snapshotLabels
+		resign(d)
+		return d
+	}, "раздел имен")
+	corrupt("long label", func(d []byte) []byte {
+		d[len(d)-5] = snapshotLabels
+		d = append(d[:len(d)-4], 0xff, 0xff, 0xff, 0x0f, 0, 0, 0, 0, 0, 0, 0, 0)
+		resign(d)
+		return d
+	}, "выходит за конец")
+	corrupt("short coords", func(d []byte) []byte {
+		d[len(d)-5] = snapshotCoords
+		d = append(d[:len(d)-4], make([]byte, 16+4)...)
+		resign(d)
+		return d
+	}, "раздела координат")
+}
+
+func TestSnapshotRoundTripsVertexData(t *testing.T) {
+	g, _, err := LoadGraphFile(writeTempGraph(t, "3 2 1 bar\n0 1 5 2\n1 2 3 0\nlabels\n0 MSK\n2 SPB\ncoords\n0 37.62 55.75\n2 30.31 59.94\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, c, err := DecodeSnapshot(snapshotBytes(t, g, Constraint{BarrierLimit, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (Constraint{BarrierLimit, 1}) || !reflect.DeepEqual(loaded.Labels(), []string{"MSK", "", "SPB"}) {
+		t.Fatalf("got %v %q", c, loaded.Labels())
+	}
+	if v, err := loaded.VertexIndex("SPB"); err != nil || v != 2 {
+		t.Fatalf("SPB: got %d %v", v, err)
+	}
+	coords := loaded.Coordinates()
+	if len(coords) != 3 || coords[0] != (Coordinates{37.62, 55.75}) || !math.IsNaN(coords[1].X) || coords[2] != (Coordinates{30.31, 59.94}) {
+		t.Fatalf("got coords %v", coords)
+	}
+	if !reflect.DeepEqual(loaded.AdjacencyList(), g.AdjacencyList()) {
+		t.Fatalf("got %v", loaded.AdjacencyList())
+	}
+}
+
+func FuzzDecodeSnapshot(f *testing.F) {
+	f.Add(snapshotBytes(f, NewGraph([][]trio{{{1, 5, Boosting}}, {{0, 3, Barrier}}}), Constraint{BarrierLimit, 1}))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) >= 4 {
+			resign(data)
+		}
+		g, c, err := DecodeSnapshot(data)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(snapshotBytes(t, g, c), data) {
+			t.Fatalf("decoded snapshot does not encode back to the same bytes")
+		}
+	})
+}
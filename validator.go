@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// Levels[i] - уровень перед i-й дугой пути, последний элемент - уровень в конце пути
+type PathCheck struct {
+	Valid    bool
+	Weight   int
+	Levels   []int
+	FailStep int
+	Reason   string
+}
+
+// ValidatePath проходит путь дуга за дугой из уровня 0 и проверяет, что каждая дуга есть в графе
+// и допустима на текущем уровне; для недопустимого пути Weight - вес пути до FailStep
+func ValidatePath(graph [][]trio, c Constraint, path []fourths) PathCheck {
+	check := PathCheck{Valid: true, FailStep: -1, Levels: make([]int, 1, len(path)+1)}
+	level := 0
+	fail := func(step int, format string, a ...interface{}) PathCheck {
+		check.Valid = false
+		check.FailStep = step
+		check.Reason = fmt.Sprintf(format, a...)
+		return check
+	}
+	for i, e := range path {
+		if e.StartPoint < 0 || e.StartPoint >= len(graph) || e.EndPoint < 0 || e.EndPoint >= len(graph) {
+			return fail(i, "дуга %v выходит за пределы графа", e)
+		}
+		if i > 0 && e.StartPoint != path[i-1].EndPoint {
+			return fail(i, "дуга %v не продолжает путь из вершины %d", e, path[i-1].EndPoint)
+		}
+		if !ContainsEdge(graph[e.StartPoint], trio{e.EndPoint, e.Weight, e.EdgeType}) {
+			return fail(i, "дуги %v нет в графе", e)
+		}
+		next, ok := NextLevel(c, e.EdgeType, level, ContainsMagnetEdges(graph[e.StartPoint]))
+		if !ok {
+			return fail(i, "дуга %v недопустима на уровне %d", e, level)
+		}
+		level = next
+		check.Weight += e.Weight
+		check.Levels = append(check.Levels, level)
+	}
+	return check
+}
+
+func ContainsEdge(edges []trio, edge trio) bool {
+	for _, e := range edges {
+		if e == edge {
+			return true
+		}
+	}
+	return false
+}
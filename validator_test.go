@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNextLevelMatchesOracle(t *testing.T) {
+	magnetVertex := [][]trio{{{0, 1, Magnet}}}
+	plainVertex := [][]trio{{{0, 1, Normal}}}
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for level := 0; level < 4; level++ {
+			c := Constraint{limit, level}
+			for l := 0; l < c.Levels(); l++ {
+				for edgeType := Normal; edgeType <= Magnet; edgeType++ {
+					for _, graph := range [][][]trio{magnetVertex, plainVertex} {
+						wantLevel, wantOk := oracleStep(graph, limit, level, 0, l, trio{0, 1, edgeType})
+						gotLevel, gotOk := NextLevel(c, edgeType, l, ContainsMagnetEdges(graph[0]))
+						if gotOk != wantOk || gotOk && gotLevel != wantLevel {
+							t.Errorf("%v level %d: edge %v from %d (magnet %v): got %d %v, want %d %v",
+								limit, level, edgeType, l, ContainsMagnetEdges(graph[0]), gotLevel, gotOk, wantLevel, wantOk)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestValidatePathReportsFirstViolation(t *testing.T) {
+	graph := [][]trio{
+		{{1, 2, Normal}, {2, 1, Barrier}},
+		{{2, 3, Boosting}},
+		{{0, 4, Barrier}},
+	}
+	c := Constraint{BarrierLimit, 1}
+
+	check := ValidatePath(graph, c, []fourths{{0, 1, 2, Normal}, {1, 2, 3, Boosting}, {2, 0, 4, Barrier}})
+	if !check.Valid || check.Weight != 9 || check.FailStep != -1 {
+		t.Fatalf("valid path: got %+v", check)
+	}
+	if want := []int{0, 0, 1, 0}; !equalInts(check.Levels, want) {
+		t.Fatalf("levels: got %v, want %v", check.Levels, want)
+	}
+
+	check = ValidatePath(graph, c, []fourths{{0, 1, 2, Normal}, {1, 2, 3, Boosting}, {2, 0, 4, Barrier}, {0, 2, 1, Barrier}})
+	if check.Valid || check.FailStep != 3 || check.Weight != 9 {
+		t.Fatalf("barrier below level: got %+v", check)
+	}
+
+	check = ValidatePath(graph, c, []fourths{{0, 1, 2, Normal}, {1, 2, 5, Boosting}})
+	if check.Valid || check.FailStep != 1 || check.Weight != 2 {
+		t.Fatalf("wrong weight: got %+v", check)
+	}
+
+	check = ValidatePath(graph, c, []fourths{{0, 1, 2, Normal}, {2, 0, 4, Barrier}})
+	if check.Valid || check.FailStep != 1 {
+		t.Fatalf("broken path: got %+v", check)
+	}
+}
+
+func equalInts(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSourcePathsAreValid(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 1000; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			n := len(graph)
+			finish := rnd.Intn(n)
+			c := Constraint{limit, level}
+
+			var auxGraph [][]duo
+			switch limit {
+			case MixLimit:
+				auxGraph, level = MakeAuxiliaryGraphForMix(graph), 1
+			case BarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForBarrier(graph, level)
+			case MagnetLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnet(graph, level)
+			case MagnetBarrierLimit:
+				auxGraph = MakeAuxiliaryGraphForMagnetBarrier(graph, level)
+			}
+			path, dist := DeijkstraAlgorithmForAuxGraph(auxGraph, 0, finish, level, n)
+			if dist == inf {
+				continue
+			}
+			var sourcePath []fourths
+			if limit == MixLimit {
+				sourcePath = MakeSourcePathForMix(path, auxGraph, n)
+			} else {
+				sourcePath = MakeSourcePathForBarrier(path, graph, c)
+			}
+			check := ValidatePath(graph, c, sourcePath)
+			if !check.Valid || check.Weight != dist {
+				t.Fatalf("%v level %d: path %v, dist %d: %+v\n%v", limit, c.Level, sourcePath, dist, check, graph)
+			}
+		}
+	}
+}
+
+func TestSourcePathWithParallelEdges(t *testing.T) {
+	//барьер на уровне 0 не пройти, поэтому путь идет по более тяжелой обычной дуге, а в mix из параллельных обычных дуг выбирается самая легкая
+	graph := [][]trio{{{1, 5, Barrier}, {1, 7, Normal}, {1, 9, Normal}}, nil}
+	c := Constraint{BarrierLimit, 1}
+	path, dist := DeijkstraAlgorithmForAuxGraph(MakeAuxiliaryGraphForBarrier(graph, 1), 0, 1, 1, 2)
+	if got := MakeSourcePathForBarrier(path, graph, c); dist != 7 || len(got) != 1 || got[0] != (fourths{0, 1, 7, Normal}) {
+		t.Fatalf("barrier: got %v, dist %d", got, dist)
+	}
+
+	graph = [][]trio{{{1, 9, Normal}, {1, 4, Normal}, {1, 6, Closed}}, nil}
+	auxGraph := MakeAuxiliaryGraphForMix(graph)
+	path, dist = DeijkstraAlgorithmForAuxGraph(auxGraph, 0, 1, 1, 2)
+	if got := MakeSourcePathForMix(path, auxGraph, 2); dist != 4 || len(got) != 1 || got[0] != (fourths{0, 1, 4, Normal}) {
+		t.Fatalf("mix: got %v, dist %d", got, dist)
+	}
+}
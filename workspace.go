@@ -0,0 +1,255 @@
+package main
+
+import "sync"
+
+// indexItem - состояние level*n+vertex с расстоянием, под которым оно положено в кучу
+type indexItem struct {
+	Index int
+	Dist  int
+}
+
+// indexHeap - двоичная куча без упаковки элементов в interface{}, чтобы вставка не выделяла память
+type indexHeap []indexItem
+
+func (h *indexHeap) push(item indexItem) {
+	*h = append(*h, item)
+	q := *h
+	for i := len(q) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if q[parent].Dist <= q[i].Dist {
+			break
+		}
+		q[parent], q[i] = q[i], q[parent]
+		i = parent
+	}
+}
+
+func (h *indexHeap) pop() indexItem {
+	q := *h
+	top := q[0]
+	last := len(q) - 1
+	q[0] = q[last]
+	q = q[:last]
+	for i := 0; ; {
+		least, left, right := i, 2*i+1, 2*i+2
+		if left < last && q[left].Dist < q[least].Dist {
+			least = left
+		}
+		if right < last && q[right].Dist < q[least].Dist {
+			least = right
+		}
+		if least == i {
+			break
+		}
+		q[least], q[i] = q[i], q[least]
+		i = least
+	}
+	*h = q
+	return top
+}
+
+// vectorWorkspace хранит массивы векторного алгоритма одним куском на все уровни
+// (индекс level*n+vertex), метки извлечения - битовым множеством. Векторные решатели хранят в prev
+// предыдущую вершину и код перехода, а поиск по правилам NextLevel (relaxState) - номер
+// предыдущего состояния и индекс дуги
+type vectorWorkspace struct {
+	n      int
+	levels int
+	dists  []int
+	labels []uint64
+	prev   []duoPath
+	finish []uint64
+	queue  indexHeap
+	//heuristic - оценка A* расстояния до финиша (nil - оценка 0); ключ состояния в куче равен dist+heuristic(v)
+	heuristic func(int) int
+}
+
+var vectorWorkspaces = sync.Pool{New: func() interface{} { return new(vectorWorkspace) }}
+
+// reset подготавливает рабочие массивы для графа из n вершин с levels уровнями, переиспользуя память
+func (w *vectorWorkspace) reset(n int, levels int, sources []Source, finishPoints []int) {
+	size := n * levels
+	w.n, w.levels = n, levels
+	if cap(w.dists) < size {
+		w.dists, w.prev = make([]int, size), make([]duoPath, size)
+	}
+	w.dists, w.prev = w.dists[:size], w.prev[:size]
+	w.labels = resetBitset(w.labels, size)
+	w.finish = resetBitset(w.finish, n)
+	w.queue = w.queue[:0]
+	w.heuristic = nil
+	for i := range w.dists {
+		w.dists[i] = int(^uint(0) >> 1)
+		w.prev[i] = duoPath{-1, 0}
+	}
+	for _, f := range finishPoints {
+		w.finish[f/64] |= 1 << uint(f%64)
+	}
+	for _, s := range sources {
+		w.relax(s.Vertex, s.Level, s.Offset, -1, 0)
+	}
+}
+
+func resetBitset(b []uint64, size int) []uint64 {
+	words := (size + 63) / 64
+	if cap(b) < words {
+		return make([]uint64, words)
+	}
+	b = b[:words]
+	for i := range b {
+		b[i] = 0
+	}
+	return b
+}
+
+func (w *vectorWorkspace) estimate(v int) int {
+	if w.heuristic == nil {
+		return 0
+	}
+	return w.heuristic(v)
+}
+
+// relax улучшает расстояние до (to, level), запоминая предыдущую вершину и тип перехода
+func (w *vectorWorkspace) relax(to int, level int, dist int, prevPoint int, edgeType int) {
+	i := level*w.n + to
+	if dist < w.dists[i] {
+		w.dists[i] = dist
+		w.prev[i] = duoPath{prevPoint, edgeType}
+		//без эвристики извлеченное состояние не улучшается; при несогласованной эвристике его открывают заново
+		w.labels[i/64] &^= 1 << uint(i%64)
+		w.queue.push(indexItem{i, dist + w.estimate(to)})
+	}
+}
+
+// peek отбрасывает устаревшие элементы кучи и возвращает ключ ближайшего неизвлеченного состояния;
+// ok=false, если таких нет
+func (w *vectorWorkspace) peek() (key int, ok bool) {
+	for len(w.queue) > 0 {
+		item := w.queue[0]
+		if item.Dist > w.dists[item.Index]+w.estimate(item.Index%w.n) || w.labels[item.Index/64]&(1<<uint(item.Index%64)) != 0 {
+			w.queue.pop()
+			continue
+		}
+		return item.Dist, true
+	}
+	return 0, false
+}
+
+// next извлекает неизвлеченное состояние с наименьшим ключом; ok=false, если таких нет
+func (w *vectorWorkspace) next() (v int, level int, ok bool) {
+	if _, ok := w.peek(); !ok {
+		return -1, 0, false
+	}
+	item := w.queue.pop()
+	w.labels[item.Index/64] |= 1 << uint(item.Index%64)
+	return item.Index % w.n, item.Index / w.n, true
+}
+
+// relaxState - relax для поиска по правилам NextLevel: запоминает предыдущее состояние (from, fromLevel)
+// и индекс дуги edge в упакованных массивах графа
+func (w *vectorWorkspace) relaxState(to int, level int, dist int, from int, fromLevel int, edge int) {
+	w.relax(to, level, dist, fromLevel*w.n+from, edge)
+}
+
+// prevState возвращает предыдущее состояние и дугу, записанные relaxState; from=-1 для стартового состояния
+func (w *vectorWorkspace) prevState(v int, level int) (from int, fromLevel int, edge int) {
+	p := w.prev[level*w.n+v]
+	if p.PrevPoint == -1 {
+		return -1, 0, -1
+	}
+	return p.PrevPoint % w.n, p.PrevPoint / w.n, p.EdgeType
+}
+
+// expand проходит дуги из извлеченного состояния (v, level) по правилам NextLevel;
+// allow (может быть nil) отбрасывает переход по дуге с индексом edge в состояние to
+func (w *vectorWorkspace) expand(graph *Graph, c Constraint, v int, level int, allow func(from statePoint, edge int, to statePoint) bool) {
+	dist, magnet := w.dist(v, level), graph.MagnetVertex(v)
+	for i := graph.Offsets[v]; i < graph.Offsets[v+1]; i++ {
+		next, ok := NextLevel(c, EdgeType(graph.Types[i]), level, magnet)
+		if !ok {
+			continue
+		}
+		to := int(graph.Targets[i])
+		if allow == nil || allow(statePoint{v, level}, i, statePoint{to, next}) {
+			w.relaxState(to, next, dist+graph.Weights[i], v, level, i)
+		}
+	}
+}
+
+// shortestStatePath извлекает состояния, пока финиш не извлечен на каком-либо уровне, и восстанавливает
+// путь до него; состояния финиша не раскрываются. nil, если финиш недостижим
+func (w *vectorWorkspace) shortestStatePath(graph *Graph, c Constraint, finishPoint int, allow func(from statePoint, edge int, to statePoint) bool) *statePath {
+	for {
+		v, level, ok := w.next()
+		if !ok {
+			return nil
+		}
+		if v == finishPoint {
+			return w.tracePath(v, level)
+		}
+		w.expand(graph, c, v, level, allow)
+	}
+}
+
+// tracePath восстанавливает путь по состояниям до (v, level), найденный через relaxState
+func (w *vectorWorkspace) tracePath(v int, level int) *statePath {
+	path := &statePath{dist: w.dist(v, level)}
+	for v != -1 {
+		path.states = append(path.states, statePoint{v, level})
+		from, fromLevel, edge := w.prevState(v, level)
+		if from != -1 {
+			path.edges = append(path.edges, edge)
+		}
+		v, level = from, fromLevel
+	}
+	for i, j := 0, len(path.states)-1; i < j; i, j = i+1, j-1 {
+		path.states[i], path.states[j] = path.states[j], path.states[i]
+	}
+	reverseInts(path.edges)
+	return path
+}
+
+func (w *vectorWorkspace) dist(v int, level int) int {
+	return w.dists[level*w.n+v]
+}
+
+func (w *vectorWorkspace) prevPoint(v int, level int) duoPath {
+	return w.prev[level*w.n+v]
+}
+
+func (w *vectorWorkspace) isFinish(v int) bool {
+	return w.finish[v/64]&(1<<uint(v%64)) != 0
+}
+
+// bestFinish возвращает вершину из finishPoints и допустимый уровень с наименьшим расстоянием
+// или (-1, 0), если ни один финиш не достижим
+func (w *vectorWorkspace) bestFinish(finishPoints []int, acceptLevel func(int) bool) (int, int) {
+	v, k := -1, 0
+	for _, f := range finishPoints {
+		for l := 0; l < w.levels; l++ {
+			d := w.dist(f, l)
+			if d != int(^uint(0)>>1) && (acceptLevel == nil || acceptLevel(l)) && (v == -1 || d < w.dist(v, k)) {
+				v, k = f, l
+			}
+		}
+	}
+	return v, k
+}
+
+// potential копирует расстояния в таблицу [вершина][уровень]
+func (w *vectorWorkspace) potential() [][]int {
+	table := make([][]int, w.n)
+	for v := range table {
+		table[v] = make([]int, w.levels)
+		for l := range table[v] {
+			table[v][l] = w.dist(v, l)
+		}
+	}
+	return table
+}
+
+func reverseInts(a []int) {
+	for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+		a[i], a[j] = a[j], a[i]
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func benchmarkGraph(limit LimitType, n int) (*Graph, Constraint) {
+	cfg := DefaultGeneratorConfig(limit)
+	cfg.N, cfg.M, cfg.Level = n, 3*n, 2
+	cfg.Seed = 1
+	graph, err := GenerateGraph(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return NewGraph(graph), Constraint{limit, cfg.Level}
+}
+
+func TestIndexHeapOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(17))
+	var h indexHeap
+	for i := 0; i < 1000; i++ {
+		h.push(indexItem{i, rnd.Intn(100)})
+	}
+	for prev := -1; len(h) > 0; {
+		item := h.pop()
+		if item.Dist < prev {
+			t.Fatalf("popped %d after %d", item.Dist, prev)
+		}
+		prev = item.Dist
+	}
+}
+
+func TestVectorWorkspaceIsReused(t *testing.T) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 2000)
+		query := func() { DeijkstraVectorAlgorithmOnGraph(g, c, 0, g.N()-1, LevelSpec{}) }
+		query()
+		//после прогрева выделяется только память под путь и служебные значения, но не массивы на каждую вершину
+		if allocs := testing.AllocsPerRun(20, query); allocs > 20 {
+			t.Fatalf("%v: %.0f allocations per query", limit, allocs)
+		}
+	}
+}
+
+// nestedVectorAlgorithm - векторный алгоритм в прежней раскладке: таблицы [вершина][уровень] и выбор
+// следующего состояния линейным просмотром; нужен только для сравнения в BenchmarkVectorAlgorithm
+func nestedVectorAlgorithm(graph *Graph, c Constraint, startPoint int, finishPoint int) int {
+	n, levels := graph.N(), c.Levels()
+	dists, labels, prevPoints := make([][]int, n), make([][]bool, n), make([][]duoPath, n)
+	for v := range dists {
+		dists[v], labels[v], prevPoints[v] = make([]int, levels), make([]bool, levels), make([]duoPath, levels)
+		for l := range dists[v] {
+			dists[v][l] = int(^uint(0) >> 1)
+			prevPoints[v][l] = duoPath{-1, 0}
+		}
+	}
+	dists[startPoint][0] = 0
+	for {
+		v, level := -1, 0
+		for l := 0; l < levels; l++ {
+			for j := 0; j < n; j++ {
+				if !labels[j][l] && dists[j][l] != int(^uint(0)>>1) && (v == -1 || dists[j][l] < dists[v][level]) {
+					v, level = j, l
+				}
+			}
+		}
+		if v == -1 {
+			return int(^uint(0) >> 1)
+		}
+		if v == finishPoint {
+			return dists[v][level]
+		}
+		labels[v][level] = true
+		for i := graph.Offsets[v]; i < graph.Offsets[v+1]; i++ {
+			e := graph.Edge(i)
+			next, ok := NextLevel(c, e.EdgeType, level, graph.MagnetVertex(v))
+			if ok && dists[v][level]+e.Weight < dists[e.EndPoint][next] {
+				dists[e.EndPoint][next] = dists[v][level] + e.Weight
+				prevPoints[e.EndPoint][next] = duoPath{v, int(e.EdgeType)}
+			}
+		}
+	}
+}
+
+func TestNestedVectorAlgorithmMatchesWorkspace(t *testing.T) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 300)
+		for finish := 0; finish < g.N(); finish += 7 {
+			_, want := DeijkstraVectorAlgorithmOnGraph(g, c, 0, finish, LevelSpec{})
+			if got := nestedVectorAlgorithm(g, c, 0, finish); got != want {
+				t.Fatalf("%v: 0 -> %d: got %d, want %d", limit, finish, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkVectorAlgorithm(b *testing.B) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for _, n := range []int{1000, 1000000} {
+			g, c := benchmarkGraph(limit, n)
+			b.Run(fmt.Sprintf("%v/n=%d", limit, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					DeijkstraVectorAlgorithmOnGraph(g, c, 0, n-1, LevelSpec{})
+				}
+			})
+			//прежняя раскладка квадратична по числу состояний, поэтому сравнивается только на малом графе
+			if n <= 1000 {
+				b.Run(fmt.Sprintf("%v/n=%d/nested", limit, n), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						nestedVectorAlgorithm(g, c, 0, n-1)
+					}
+				})
+			}
+			//ни один уровень финиша не допустим, поэтому извлекаются все достижимые состояния
+			settleAll := LevelSpec{AcceptLevel: func(int) bool { return false }}
+			b.Run(fmt.Sprintf("%v/n=%d/settle", limit, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					DeijkstraVectorAlgorithmOnGraph(g, c, 0, n-1, settleAll)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+// Levels[i] - уровень в i-й вершине пути, Edges[i] ведет из i-й вершины в (i+1)-ю
+type KShortestPath struct {
+	Edges  []fourths
+	Levels []int
+	Dist   int
+}
+
+// MinDistinct - наименьшая доля дуг пути, не общих ни с одним из уже выбранных путей
+// (0 - без ограничения, 1 - пути без общих дуг); MaxCandidates ограничивает число рассмотренных путей,
+// 0 - без ограничения. Нулевое значение YenOptions ничего не ограничивает
+type YenOptions struct {
+	MinDistinct   float64
+	MaxCandidates int
+}
+
+func DefaultYenOptions() YenOptions {
+	return YenOptions{}
+}
+
+// statePath - путь по состояниям (вершина, уровень); edges[i] - индекс дуги в упакованных массивах графа
+type statePath struct {
+	states []statePoint
+	edges  []int
+	dist   int
+}
+
+// vertices возвращает вершины пути без уровней
+func (p *statePath) vertices() []int {
+	path := make([]int, len(p.states))
+	for i, s := range p.states {
+		path[i] = s.Vertex
+	}
+	return path
+}
+
+type stateArc struct {
+	From statePoint
+	Edge int
+}
+
+func (p *statePath) equal(q *statePath) bool {
+	if p.dist != q.dist || len(p.edges) != len(q.edges) {
+		return false
+	}
+	for i := range p.edges {
+		if p.states[i] != q.states[i] || p.edges[i] != q.edges[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasRoot проверяет, что путь начинается с первых i+1 состояний root
+func (p *statePath) hasRoot(root *statePath, i int) bool {
+	if len(p.states) <= i {
+		return false
+	}
+	for j := 0; j < i; j++ {
+		if p.states[j] != root.states[j] || p.edges[j] != root.edges[j] {
+			return false
+		}
+	}
+	return p.states[i] == root.states[i]
+}
+
+// spurPath ищет кратчайший путь из source до финиша на любом уровне, не заходя в запрещенные
+// состояния и не используя запрещенные дуги; состояния финиша считаются конечными
+func spurPath(graph *Graph, c Constraint, source statePoint, finishPoint int,
+	bannedStates map[statePoint]bool, bannedArcs map[stateArc]bool) *statePath {
+	w := vectorWorkspaces.Get().(*vectorWorkspace)
+	defer vectorWorkspaces.Put(w)
+	w.reset(graph.N(), c.Levels(), []Source{{source.Vertex, source.Level, 0}}, nil)
+	return w.shortestStatePath(graph, c, finishPoint, func(from statePoint, edge int, to statePoint) bool {
+		return !bannedStates[to] && !bannedArcs[stateArc{from, edge}]
+	})
+}
+
+func (p *statePath) toKShortestPath(graph *Graph) KShortestPath {
+	result := KShortestPath{Edges: make([]fourths, len(p.edges)), Levels: make([]int, len(p.states)), Dist: p.dist}
+	for i, s := range p.states {
+		result.Levels[i] = s.Level
+	}
+	for i, j := range p.edges {
+		e := graph.Edge(j)
+		result.Edges[i] = fourths{p.states[i].Vertex, e.EndPoint, e.Weight, e.EdgeType}
+	}
+	return result
+}
+
+// overlap возвращает долю дуг пути p, встречающихся в пути q
+func overlap(p KShortestPath, q KShortestPath) float64 {
+	if len(p.Edges) == 0 {
+		return 0
+	}
+	inQ := make(map[fourths]int, len(q.Edges))
+	for _, e := range q.Edges {
+		inQ[e]++
+	}
+	shared := 0
+	for _, e := range p.Edges {
+		if inQ[e] > 0 {
+			inQ[e]--
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(p.Edges))
+}
+
+// YenKShortestPaths возвращает до k путей в порядке возрастания длины по алгоритму Йена.
+// Пути не повторяют состояний (вершина, уровень): вершину можно пройти повторно на другом
+// уровне, как того требуют барьерные и магнитные ограничения; путь заканчивается в финише
+func YenKShortestPaths(graph [][]trio, c Constraint, startPoint int, finishPoint int, k int, opts YenOptions) []KShortestPath {
+	return YenKShortestPathsOnGraph(NewGraph(graph), c, startPoint, finishPoint, k, opts)
+}
+
+func YenKShortestPathsOnGraph(graph *Graph, c Constraint, startPoint int, finishPoint int, k int, opts YenOptions) []KShortestPath {
+	result := make([]KShortestPath, 0, k)
+	first := spurPath(graph, c, statePoint{startPoint, 0}, finishPoint, nil, nil)
+	if first == nil || k <= 0 {
+		return result
+	}
+
+	var found, candidates []*statePath
+	next := first
+	for next != nil && len(result) < k && (opts.MaxCandidates <= 0 || len(found) < opts.MaxCandidates) {
+		found = append(found, next)
+		path := next.toKShortestPath(graph)
+		accepted := true
+		for _, q := range result {
+			if overlap(path, q) > 1-opts.MinDistinct {
+				accepted = false
+				break
+			}
+		}
+		if accepted {
+			result = append(result, path)
+		}
+
+		for i := 0; i+1 < len(next.states); i++ {
+			bannedArcs := make(map[stateArc]bool)
+			for _, p := range found {
+				if p.hasRoot(next, i) && len(p.edges) > i {
+					bannedArcs[stateArc{p.states[i], p.edges[i]}] = true
+				}
+			}
+			bannedStates := make(map[statePoint]bool, i)
+			rootDist := 0
+			for j := 0; j < i; j++ {
+				bannedStates[next.states[j]] = true
+				rootDist += graph.Weights[next.edges[j]]
+			}
+			spur := spurPath(graph, c, next.states[i], finishPoint, bannedStates, bannedArcs)
+			if spur == nil {
+				continue
+			}
+			candidate := &statePath{
+				states: append(append([]statePoint{}, next.states[:i]...), spur.states...),
+				edges:  append(append([]int{}, next.edges[:i]...), spur.edges...),
+				dist:   rootDist + spur.dist,
+			}
+			duplicate := false
+			for _, p := range candidates {
+				if p.equal(candidate) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		next = nil
+		best := -1
+		for i, p := range candidates {
+			if best == -1 || p.dist < candidates[best].dist {
+				best = i
+			}
+		}
+		if best != -1 {
+			next = candidates[best]
+			candidates = append(candidates[:best], candidates[best+1:]...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// oracleAllDistances перебирает длины всех путей без повторения состояний, заканчивающихся
+// в финише; при числе путей больше maxPaths возвращает false
+func oracleAllDistances(graph [][]trio, limit LimitType, level int, start int, finish int, maxPaths int) ([]int, bool) {
+	levels := oracleLevels(limit, level)
+	visited := make([][]bool, len(graph))
+	for i := range visited {
+		visited[i] = make([]bool, levels)
+	}
+	var dists []int
+	var dfs func(v int, l int, dist int)
+	dfs = func(v int, l int, dist int) {
+		if len(dists) > maxPaths {
+			return
+		}
+		if v == finish {
+			dists = append(dists, dist)
+			return
+		}
+		visited[v][l] = true
+		for _, e := range graph[v] {
+			if next, ok := oracleStep(graph, limit, level, v, l, e); ok && !visited[e.EndPoint][next] {
+				dfs(e.EndPoint, next, dist+e.Weight)
+			}
+		}
+		visited[v][l] = false
+	}
+	dfs(start, 0, 0)
+	sort.Ints(dists)
+	return dists, len(dists) <= maxPaths
+}
+
+func TestYenMatchesOracle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+	const k = 6
+	for _, limit := range []LimitType{MixLimit, BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		for i := 0; i < 500; i++ {
+			graph, level := randomTestGraph(rnd, limit)
+			finish := rnd.Intn(len(graph))
+			want, ok := oracleAllDistances(graph, limit, level, 0, finish, 10000)
+			if !ok {
+				continue
+			}
+			if len(want) > k {
+				want = want[:k]
+			}
+			c := Constraint{limit, level}
+			//нулевое значение параметров не отбрасывает пути с общими дугами
+			paths := YenKShortestPaths(graph, c, 0, finish, k, YenOptions{})
+			if len(paths) != len(want) {
+				t.Fatalf("%v level %d finish %d: got %d paths, want %d\n%v", limit, level, finish, len(paths), len(want), graph)
+			}
+			for j, p := range paths {
+				if p.Dist != want[j] {
+					t.Fatalf("%v level %d finish %d: path %d has length %d, want %d\n%v", limit, level, finish, j, p.Dist, want[j], graph)
+				}
+				check := ValidatePath(graph, c, p.Edges)
+				if !check.Valid || check.Weight != p.Dist || !equalInts(check.Levels, p.Levels) {
+					t.Fatalf("%v level %d: path %v is invalid: %s", limit, level, p.Edges, check.Reason)
+				}
+			}
+		}
+	}
+}
+
+func TestYenRespectsMinDistinct(t *testing.T) {
+	rnd := rand.New(rand.NewSource(9))
+	for i := 0; i < 500; i++ {
+		graph, level := randomTestGraph(rnd, BarrierLimit)
+		finish := rnd.Intn(len(graph))
+		opts := YenOptions{MinDistinct: 0.5}
+		paths := YenKShortestPaths(graph, Constraint{BarrierLimit, level}, 0, finish, 4, opts)
+		for a := range paths {
+			if a > 0 && paths[a].Dist < paths[a-1].Dist {
+				t.Fatalf("paths are not ordered: %d after %d", paths[a].Dist, paths[a-1].Dist)
+			}
+			for b := 0; b < a; b++ {
+				if overlap(paths[a], paths[b]) > 1-opts.MinDistinct {
+					t.Fatalf("paths %v and %v overlap too much", paths[a].Edges, paths[b].Edges)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkYen(b *testing.B) {
+	for _, limit := range []LimitType{BarrierLimit, MagnetLimit, MagnetBarrierLimit} {
+		g, c := benchmarkGraph(limit, 4000)
+		b.Run(fmt.Sprint(limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				YenKShortestPathsOnGraph(g, c, 0, g.N()-1, 5, YenOptions{})
+			}
+		})
+	}
+}